@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// stdinTarget is the positional argument that selects stdin as scan input
+const stdinTarget = "-"
+
+// readPathList reads a newline- or NUL-separated list of paths, as produced by
+// `git diff --name-only` or `find -print0`. Blank entries are ignored.
+func readPathList(r io.Reader, nulSeparated bool) ([]string, error) {
+	sep := byte('\n')
+	if nulSeparated {
+		sep = 0
+	}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	s.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, sep); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+
+	var paths []string
+	for s.Scan() {
+		p := s.Text()
+		if !nulSeparated {
+			p = strings.TrimRight(p, "\r")
+		}
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		paths = append(paths, p)
+	}
+	return paths, s.Err()
+}
+
+// readPathListFile reads a path list from a file, or from stdin when path is "-"
+func readPathListFile(path string, nulSeparated bool) ([]string, error) {
+	if path == stdinTarget {
+		return readPathList(os.Stdin, nulSeparated)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readPathList(f, nulSeparated)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestReadPathList verifies newline- and NUL-separated path lists
+func TestReadPathList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		nul   bool
+		want  []string
+	}{
+		{"newline", "a.go\nsub dir/b.env\r\n\n", false, []string{"a.go", "sub dir/b.env"}},
+		{"no trailing newline", "a.go\nb.go", false, []string{"a.go", "b.go"}},
+		{"nul", "a.go\x00name\nwith newline\x00", true, []string{"a.go", "name\nwith newline"}},
+		{"empty", "", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readPathList(strings.NewReader(tt.input), tt.nul)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readPathList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// schemaValidator checks decoded JSON against the subset of JSON Schema used by
+// schema/report.schema.json: type, properties, required, additionalProperties,
+// items, enum, const, minimum, maximum, minLength, pattern and local $ref.
+type schemaValidator struct {
+	root   map[string]interface{}
+	errors []string
+}
+
+func (v *schemaValidator) fail(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+// resolve follows a local "#/..." reference within the root schema
+func (v *schemaValidator) resolve(ref string) (map[string]interface{}, bool) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	var node interface{} = v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		node = m[part]
+	}
+	m, ok := node.(map[string]interface{})
+	return m, ok
+}
+
+// jsonType returns the JSON Schema type name of a decoded value
+func jsonType(value interface{}) string {
+	switch x := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if x == math.Trunc(x) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// typeMatches reports whether a value satisfies a schema "type" keyword
+func typeMatches(want interface{}, value interface{}) bool {
+	actual := jsonType(value)
+	check := func(t string) bool {
+		return t == actual || (t == "number" && actual == "integer")
+	}
+	switch w := want.(type) {
+	case string:
+		return check(w)
+	case []interface{}:
+		for _, t := range w {
+			if s, ok := t.(string); ok && check(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *schemaValidator) validate(schema map[string]interface{}, value interface{}, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		target, found := v.resolve(ref)
+		if !found {
+			v.fail(path, "unresolvable schema reference %s", ref)
+			return
+		}
+		v.validate(target, value, path)
+		return
+	}
+
+	if want, ok := schema["type"]; ok && !typeMatches(want, value) {
+		v.fail(path, "expected type %v, got %s", want, jsonType(value))
+		return
+	}
+
+	if c, ok := schema["const"]; ok && fmt.Sprint(c) != fmt.Sprint(value) {
+		v.fail(path, "expected %v, got %v", c, value)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if fmt.Sprint(e) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value %v is not one of %v", value, enum)
+		}
+	}
+
+	switch x := value.(type) {
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && x < min {
+			v.fail(path, "value %v is less than minimum %v", x, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && x > max {
+			v.fail(path, "value %v is greater than maximum %v", x, max)
+		}
+	case string:
+		if minLen, ok := schema["minLength"].(float64); ok && float64(len(x)) < minLen {
+			v.fail(path, "string is shorter than %v characters", minLen)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				v.fail(path, "invalid schema pattern %q", pattern)
+			} else if !re.MatchString(x) {
+				v.fail(path, "value %q does not match pattern %s", x, pattern)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range x {
+				v.validate(items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case map[string]interface{}:
+		v.validateObject(schema, x, path)
+	}
+}
+
+func (v *schemaValidator) validateObject(schema map[string]interface{}, obj map[string]interface{}, path string) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, present := obj[name]; !present {
+				v.fail(path, "missing required property %q", name)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	// Iterate in sorted order so error output is deterministic
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		child := path + "." + key
+		if propSchema, ok := properties[key].(map[string]interface{}); ok {
+			v.validate(propSchema, obj[key], child)
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				v.fail(path, "unexpected property %q", key)
+			}
+		case map[string]interface{}:
+			v.validate(extra, obj[key], child)
+		}
+	}
+}
@@ -12,875 +12,56 @@
 //	secscan -root .                      # scan working tree + git history
 //	secscan -root . -history=false       # scan only current files
 //	secscan -root . -json report.json    # output JSON report
+//	secscan -root . -format sarif -output report.sarif  # output SARIF 2.1.0 log
+//	secscan -root . -format junit -output junit.xml -format csv -output findings.csv
 //	secscan -root . -config .secscan.toml  # use custom config
 //	secscan -root . -entropy 5.5         # adjust entropy threshold
 //	secscan -root . -verbose             # show detailed output
 //	secscan -root . -respect-gitignore=false  # disable gitignore support
+//	secscan -root . -fail-on high -min-confidence 0.8  # only fail on confident, high-impact findings
+//	git diff | secscan scan -stdin-name patch.diff -   # scan content piped on stdin
+//	git diff --name-only -z | secscan -files-from - -0  # scan an explicit file list
+//	secscan image -format sarif -output image.sarif app.tar  # scan a docker save or OCI image
+//	secscan report validate report.json  # validate a JSON report against the schema
 package main
 
 import (
-	"bufio"
-	"crypto/sha256"
-	"encoding/json"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
-	"io"
-	"io/fs"
-	"math"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"regexp"
-	"sort"
+	"os/signal"
+	"strconv"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
-	"unicode/utf8"
+
+	"github.com/Zayan-Mohamed/secscan/scanner"
 )
 
 // Version information
 const version = "2.2.2"
 
-// Finding represents a detected secret or potential secret
-type Finding struct {
-	File       string            `json:"file"`
-	Line       int               `json:"line"`
-	Commit     string            `json:"commit,omitempty"`
-	Pattern    string            `json:"pattern"`
-	Excerpt    string            `json:"excerpt"`
-	RawValue   string            `json:"-"` // Not exported to JSON
-	Confidence float64           `json:"confidence"`
-	Verified   bool              `json:"verified"`
-	Metadata   map[string]string `json:"metadata,omitempty"`
-	Hash       string            `json:"hash"` // For deduplication
-}
-
-// Config holds scanner configuration
-type Config struct {
-	Rules             map[string]*Rule
-	SkipDirs          []string
-	SkipFiles         []string
-	AllowPatterns     []*regexp.Regexp
-	EntropyThreshold  float64
-	MinSecretLength   int
-	MaxSecretLength   int
-	Verbose           bool
-	RespectGitignore  bool
-	GitignorePatterns []GitignorePattern
-}
-
-// GitignorePattern represents a pattern from .gitignore with its base directory
-type GitignorePattern struct {
-	Pattern   string
-	Negation  bool
-	Directory bool
-	BaseDir   string
-}
-
-// Rule represents a detection rule
-type Rule struct {
-	Name        string
-	Pattern     *regexp.Regexp
-	Keywords    []string
-	Description string
-	Confidence  float64
-	Enabled     bool
-}
-
-// Stats tracks scanning statistics
-type Stats struct {
-	FilesScanned   int
-	CommitsScanned int
-	FindingsTotal  int
-	FindingsUnique int
-	StartTime      time.Time
-	EndTime        time.Time
-	mu             sync.Mutex
-}
-
-func (s *Stats) incrementFiles() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.FilesScanned++
-}
-
-func (s *Stats) incrementCommits() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.CommitsScanned++
-}
-
-func (s *Stats) incrementFindings(count int) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.FindingsTotal += count
-}
-
-// Enhanced detection patterns with lower false positive rates
-var defaultRegexps = map[string]string{
-	"aws_access_key":    `AKIA[0-9A-Z]{16}`,
-	"aws_secret_key":    `(?i)aws(.{0,20})?(?-i)['\"][0-9a-zA-Z/+]{40}['\"]`,
-	"rsa_private":       `-----BEGIN(?: RSA)? PRIVATE KEY-----`,
-	"stripe_sk":         `sk_live_[0-9a-zA-Z]{24,}`,
-	"stripe_restricted": `rk_live_[0-9a-zA-Z]{24,}`,
-	"supabase_jwt":      `eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`,
-	"github_pat":        `ghp_[0-9a-zA-Z]{36}`,
-	"github_oauth":      `gho_[0-9a-zA-Z]{36}`,
-	"github_app":        `(ghu|ghs)_[0-9a-zA-Z]{36}`,
-	"slack_token":       `xox[baprs]-([0-9a-zA-Z]{10,48})`,
-	"slack_webhook":     `https://hooks\.slack\.com/services/T[a-zA-Z0-9_]+/B[a-zA-Z0-9_]+/[a-zA-Z0-9_]+`,
-	"google_api":        `AIza[0-9A-Za-z_\-]{35}`,
-	"heroku_api":        `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
-	"mailgun_api":       `key-[0-9a-zA-Z]{32}`,
-	"paypal_braintree":  `access_token\$production\$[0-9a-z]{16}\$[0-9a-f]{32}`,
-	"picatic_api":       `sk_live_[0-9a-z]{32}`,
-	"sendgrid_api":      `SG\.[0-9A-Za-z\-_]{22}\.[0-9A-Za-z\-_]{43}`,
-	"twilio_api":        `SK[0-9a-fA-F]{32}`,
-	"generic_api_key":   `(?i)(?:key|api[_-]?key|apikey)[\s]*[=:>][\s]*['\"]([a-zA-Z0-9_\-]{20,})['\""]`,
-	"generic_secret":    `(?i)(?:secret|password|passwd|pwd)[\s]*[=:>][\s]*['\"]([a-zA-Z0-9_\-!@#$%^&*]{8,})['\""]`,
-	"db_connection":     `(?i)(postgres|mysql|mongodb|redis)://[^\s'"]+:[^\s'"]+@[^\s'"]+`,
-}
-
-// Patterns that should be allowed (common false positives)
-var defaultAllowPatterns = []string{
-	`^[A-Z_]+$`,                         // All caps constants
-	`^[a-z_]+$`,                         // All lowercase
-	`(?i)^(true|false|null|undefined)$`, // Boolean/null values
-	`^[\d.]+$`,                          // Pure numbers
-	`^https?://`,                        // URLs without credentials
-	`^[A-Za-z]+\.[A-Za-z]+`,             // Class/module names
-	`(?i)^(test|example|sample|demo|placeholder|your[_-].*|my[_-].*)`, // Test values
-	`^[*]+$`, // Masked secrets
-}
-
-// Enhanced skip directories with more comprehensive list
-var defaultSkipDirs = []string{
-	"node_modules", ".git", "dist", "build", ".next", "venv", "target",
-	"__pycache__", ".venv", "env", ".env", "vendor", "coverage",
-	".pytest_cache", ".mypy_cache", ".tox", "bin", "obj", ".gradle",
-	".idea", ".vscode", ".terraform", "*.egg-info", ".nuxt",
-}
-
-// File extensions that should be skipped
-var defaultSkipExtensions = []string{
-	".jpg", ".jpeg", ".png", ".gif", ".ico", ".svg", ".webp",
-	".mp4", ".avi", ".mov", ".mp3", ".wav", ".pdf", ".zip",
-	".tar", ".gz", ".bz2", ".7z", ".rar", ".exe", ".dll",
-	".so", ".dylib", ".bin", ".db", ".sqlite", ".lock",
-	".min.js", ".min.css", ".map", ".woff", ".woff2", ".ttf", ".eot",
-}
-
-func shouldSkipDir(d string) bool {
-	base := filepath.Base(d)
-	for _, s := range defaultSkipDirs {
-		if base == s || strings.HasPrefix(base, s) {
-			return true
-		}
-	}
-	return false
-}
-
-func shouldSkipFile(name string) bool {
-	base := filepath.Base(name)
-
-	// Skip hidden files except specific configs
-	if strings.HasPrefix(base, ".") && base != ".env" && base != ".env.example" {
-		return true
-	}
-
-	ext := strings.ToLower(filepath.Ext(name))
-	for _, skipExt := range defaultSkipExtensions {
-		if ext == skipExt {
-			return true
-		}
-	}
-
-	// Skip all types of lock files comprehensively
-	lockFilePatterns := []string{
-		".lock",             // Gemfile.lock, Pipfile.lock, etc.
-		"-lock.json",        // package-lock.json
-		"-lock.yaml",        // pnpm-lock.yaml
-		"lock.json",         // composer.lock.json
-		"lock.yaml",         // yarn.lock (though it's typically yarn.lock)
-		"yarn.lock",         // yarn lock file
-		"pnpm-lock.yaml",    // pnpm lock file
-		"package-lock.json", // npm lock file
-		"composer.lock",     // PHP composer lock
-		"Gemfile.lock",      // Ruby Gemfile lock
-		"Pipfile.lock",      // Python Pipfile lock
-		"poetry.lock",       // Python poetry lock
-		"Cargo.lock",        // Rust cargo lock
-		"mix.lock",          // Elixir mix lock
-		"pubspec.lock",      // Dart/Flutter pubspec lock
-		"go.sum",            // Go dependencies checksum
-		"Podfile.lock",      // iOS CocoaPods lock
-		"Cartfile.resolved", // iOS Carthage lock
-	}
-
-	for _, pattern := range lockFilePatterns {
-		if strings.HasSuffix(base, pattern) || base == pattern {
-			return true
-		}
-	}
-
-	return false
-}
-
-// parseGitignoreLine parses a single line from .gitignore
-func parseGitignoreLine(line, baseDir string) *GitignorePattern {
-	line = strings.TrimSpace(line)
-
-	// Skip empty lines and comments
-	if line == "" || strings.HasPrefix(line, "#") {
-		return nil
-	}
-
-	pattern := GitignorePattern{
-		BaseDir: baseDir,
-	}
-
-	// Check for negation
-	if strings.HasPrefix(line, "!") {
-		pattern.Negation = true
-		line = strings.TrimPrefix(line, "!")
-	}
-
-	// Check if pattern is for directories only
-	if strings.HasSuffix(line, "/") {
-		pattern.Directory = true
-		line = strings.TrimSuffix(line, "/")
-	}
-
-	pattern.Pattern = line
-	return &pattern
-}
-
-// loadGitignore loads patterns from a .gitignore file
-func loadGitignore(path string) ([]GitignorePattern, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	baseDir := filepath.Dir(path)
-	var patterns []GitignorePattern
-
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		if pattern := parseGitignoreLine(scanner.Text(), baseDir); pattern != nil {
-			patterns = append(patterns, *pattern)
-		}
-	}
-
-	return patterns, scanner.Err()
-}
-
-// collectGitignorePatterns finds and loads all .gitignore files in the repository
-func collectGitignorePatterns(root string) []GitignorePattern {
-	var allPatterns []GitignorePattern
-
-	// Walk the directory tree to find all .gitignore files
-	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil
-		}
-
-		// Skip .git directory
-		if d.IsDir() && d.Name() == ".git" {
-			return filepath.SkipDir
-		}
-
-		// Check if this is a .gitignore file
-		if !d.IsDir() && d.Name() == ".gitignore" {
-			patterns, err := loadGitignore(path)
-			if err == nil {
-				allPatterns = append(allPatterns, patterns...)
-			}
-		}
-
-		return nil
-	})
-
-	return allPatterns
-}
-
-// matchGitignorePattern checks if a path matches a gitignore pattern
-func matchGitignorePattern(path string, pattern GitignorePattern) bool {
-	// Convert absolute path to relative path from pattern's base directory
-	relPath, err := filepath.Rel(pattern.BaseDir, path)
-	if err != nil {
-		return false
-	}
-
-	// If the path is outside the base directory, it doesn't match
-	if strings.HasPrefix(relPath, "..") {
-		return false
-	}
-
-	patternStr := pattern.Pattern
-
-	// Handle different pattern types
-	if strings.HasPrefix(patternStr, "/") {
-		// Anchored to base directory
-		patternStr = strings.TrimPrefix(patternStr, "/")
-		return matchPattern(patternStr, relPath)
-	} else if strings.Contains(patternStr, "/") {
-		// Contains slash - match anywhere in path
-		return matchPattern(patternStr, relPath)
-	} else {
-		// No slash - match basename anywhere in tree
-		parts := strings.Split(relPath, string(filepath.Separator))
-		for _, part := range parts {
-			if matchPattern(patternStr, part) {
-				return true
-			}
-		}
-		// Also try full relative path
-		return matchPattern(patternStr, relPath)
-	}
-}
-
-// matchPattern performs glob-style pattern matching
-func matchPattern(pattern, name string) bool {
-	// Handle ** for matching any number of directories
-	if strings.Contains(pattern, "**") {
-		parts := strings.Split(pattern, "**")
-		if len(parts) == 2 {
-			prefix := strings.TrimSuffix(parts[0], "/")
-			suffix := strings.TrimPrefix(parts[1], "/")
-
-			if prefix != "" && !strings.HasPrefix(name, prefix) {
-				return false
-			}
-			if suffix != "" && !strings.HasSuffix(name, suffix) {
-				return false
-			}
-			return true
-		}
-	}
-
-	// Simple glob matching
-	matched, _ := filepath.Match(pattern, name)
-	if matched {
-		return true
-	}
-
-	// Try matching with the full path for patterns with directory separators
-	matched, _ = filepath.Match(pattern, filepath.Base(name))
-	return matched
-}
-
-// isGitignored checks if a path should be ignored based on gitignore patterns
-func isGitignored(path string, patterns []GitignorePattern, isDir bool) bool {
-	ignored := false
-
-	// Process patterns in order (later patterns override earlier ones)
-	for _, pattern := range patterns {
-		// Skip directory-only patterns for files
-		if pattern.Directory && !isDir {
-			continue
-		}
-
-		if matchGitignorePattern(path, pattern) {
-			if pattern.Negation {
-				ignored = false // Negation pattern - don't ignore
-			} else {
-				ignored = true // Normal pattern - ignore
-			}
-		}
-	}
-
-	return ignored
-}
-
-func looksLikeTextFile(name string) bool {
-	// Skip if should skip file
-	if shouldSkipFile(name) {
-		return false
-	}
-
-	ext := strings.ToLower(filepath.Ext(name))
-	switch ext {
-	case ".go", ".js", ".ts", ".tsx", ".jsx", ".java", ".py", ".rb", ".php",
-		".json", ".yaml", ".yml", ".env", ".cfg", ".toml", ".md", ".txt",
-		".sh", ".bash", ".zsh", ".ps1", ".sql", ".xml", ".html", ".css",
-		".c", ".cpp", ".h", ".hpp", ".cs", ".rs", ".kt", ".swift", ".scala",
-		".clj", ".ex", ".exs", ".erl", ".hrl", ".vim", ".lua", ".pl", ".r",
-		".Dockerfile", ".tf", ".hcl", ".proto", ".graphql", ".vue", ".svelte":
-		return true
-	default:
-		// Check if file has no extension (might be script)
-		if ext == "" {
-			return true
-		}
-		return false
-	}
-}
-
-func compileRules(rules map[string]string) (map[string]*Rule, error) {
-	out := make(map[string]*Rule, len(rules))
-	for k, v := range rules {
-		r, err := regexp.Compile(v)
-		if err != nil {
-			return nil, fmt.Errorf("failed to compile %s: %w", k, err)
-		}
-		confidence := 0.9 // Default high confidence for regex patterns
-		if strings.HasPrefix(k, "generic_") {
-			confidence = 0.7 // Lower confidence for generic patterns
-		}
-		out[k] = &Rule{
-			Name:        k,
-			Pattern:     r,
-			Description: k,
-			Confidence:  confidence,
-			Enabled:     true,
-		}
-	}
-	return out, nil
-}
-
-func compileAllowPatterns(patterns []string) ([]*regexp.Regexp, error) {
-	var out []*regexp.Regexp
-	for _, p := range patterns {
-		r, err := regexp.Compile(p)
-		if err != nil {
-			return nil, fmt.Errorf("failed to compile allow pattern %s: %w", p, err)
-		}
-		out = append(out, r)
-	}
-	return out, nil
-}
-
-// isAllowed checks if a value matches any allow pattern
-func isAllowed(value string, allowPatterns []*regexp.Regexp) bool {
-	for _, pattern := range allowPatterns {
-		if pattern.MatchString(value) {
-			return true
-		}
-	}
-	return false
-}
-
-// generateHash creates a unique hash for deduplication
-func generateHash(file, pattern, value string) string {
-	h := sha256.New()
-	h.Write([]byte(file + pattern + value))
-	return fmt.Sprintf("%x", h.Sum(nil))[:16]
-}
-
-func maskSecret(s string) string {
-	if len(s) <= 8 {
-		return strings.Repeat("*", len(s))
-	}
-	pref := s[:4]
-	suf := s[len(s)-4:]
-	return pref + strings.Repeat("*", len(s)-8) + suf
-}
-
-func stringExcerpt(line string, a, b int) string {
-	if a < 0 || b > len(line) || a >= b {
-		return strings.TrimSpace(line)
-	}
-	start := a - 20
-	if start < 0 {
-		start = 0
-	}
-	end := b + 20
-	if end > len(line) {
-		end = len(line)
-	}
-	return strings.TrimSpace(line[start:end])
-}
-
-// Improved entropy detection with adjustable threshold
-func isHighEntropy(s string, threshold float64) bool {
-	length := utf8.RuneCountInString(s)
-
-	// Must be at least 20 characters
-	if length < 20 {
-		return false
-	}
-
-	// Must have good character diversity
-	hasLower := false
-	hasUpper := false
-	hasDigit := false
-	hasSymbol := false
-
-	for _, r := range s {
-		if r >= 'a' && r <= 'z' {
-			hasLower = true
-		} else if r >= 'A' && r <= 'Z' {
-			hasUpper = true
-		} else if r >= '0' && r <= '9' {
-			hasDigit = true
-		} else {
-			hasSymbol = true
-		}
-	}
-
-	charClassCount := 0
-	for _, v := range []bool{hasLower, hasUpper, hasDigit, hasSymbol} {
-		if v {
-			charClassCount++
-		}
-	}
-
-	// Require at least 3 character classes
-	if charClassCount < 3 {
-		return false
-	}
-
-	// Calculate Shannon entropy
-	h := shannonEntropy(s)
-
-	// Check against threshold (default 5.0, increased from 4.0)
-	return h > threshold
-}
-
-func shannonEntropy(s string) float64 {
-	if len(s) == 0 {
-		return 0
-	}
-	counts := make(map[rune]int)
-	for _, r := range s {
-		counts[r]++
-	}
-	e := 0.0
-	L := float64(len(s))
-	for _, c := range counts {
-		p := float64(c) / L
-		e += -p * math.Log2(p)
-	}
-	return e
-}
-
-func walkFiles(root string, config *Config, action func(path string) error) error {
-	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			// ignore walk errors for robustness
-			return nil
-		}
-		if d.IsDir() {
-			// Check gitignore first if enabled
-			if config.RespectGitignore && isGitignored(path, config.GitignorePatterns, true) {
-				if config.Verbose {
-					fmt.Printf("Skipping gitignored directory: %s\n", path)
-				}
-				return filepath.SkipDir
-			}
-
-			// Then check default skip dirs
-			if shouldSkipDir(path) && path != root {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Check gitignore for files if enabled
-		if config.RespectGitignore && isGitignored(path, config.GitignorePatterns, false) {
-			if config.Verbose {
-				fmt.Printf("Skipping gitignored file: %s\n", path)
-			}
-			return nil
-		}
-
-		if !looksLikeTextFile(path) {
-			return nil
-		}
-		return action(path)
-	})
-}
-
-func scanFileForSecrets(path string, rules map[string]*Rule, config *Config) ([]Finding, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	var findings []Finding
-	r := bufio.NewReader(f)
-	lineNo := 0
-
-	for {
-		line, err := r.ReadString('\n')
-		if err != nil && err != io.EOF {
-			return findings, err
-		}
-		lineNo++
-		trim := strings.TrimSpace(line)
-		if len(trim) == 0 {
-			if err == io.EOF {
-				break
-			}
-			continue
-		}
-
-		// Skip comments (basic detection)
-		if strings.HasPrefix(trim, "//") || strings.HasPrefix(trim, "#") ||
-			strings.HasPrefix(trim, "/*") || strings.HasPrefix(trim, "*") {
-			if err == io.EOF {
-				break
-			}
-			continue
-		}
-
-		// Check regex rules
-		for name, rule := range rules {
-			if !rule.Enabled {
-				continue
-			}
-
-			if loc := rule.Pattern.FindStringIndex(line); loc != nil {
-				excerpt := stringExcerpt(line, loc[0], loc[1])
-				rawValue := line[loc[0]:loc[1]]
-
-				// Check if allowed
-				if isAllowed(rawValue, config.AllowPatterns) {
-					continue
-				}
-
-				findings = append(findings, Finding{
-					File:       path,
-					Line:       lineNo,
-					Pattern:    name,
-					Excerpt:    maskSecret(excerpt),
-					RawValue:   rawValue,
-					Confidence: rule.Confidence,
-					Verified:   false,
-					Hash:       generateHash(path, name, rawValue),
-				})
-			}
-		}
-
-		// Check high entropy tokens
-		if config.EntropyThreshold > 0 {
-			tokens := regexp.MustCompile(`\S{20,}`).FindAllString(line, -1)
-			for _, tok := range tokens {
-				// Skip if allowed
-				if isAllowed(tok, config.AllowPatterns) {
-					continue
-				}
-
-				if isHighEntropy(tok, config.EntropyThreshold) {
-					findings = append(findings, Finding{
-						File:       path,
-						Line:       lineNo,
-						Pattern:    "high_entropy",
-						Excerpt:    maskSecret(tok),
-						RawValue:   tok,
-						Confidence: 0.6,
-						Verified:   false,
-						Hash:       generateHash(path, "high_entropy", tok),
-					})
-				}
-			}
-		}
-
-		if err == io.EOF {
-			break
-		}
-	}
-	return findings, nil
-}
-
-// git helpers
-func gitAvailable() bool {
-	_, err := exec.LookPath("git")
-	return err == nil
-}
-
-func gitAllCommits() ([]string, error) {
-	cmd := exec.Command("git", "rev-list", "--all")
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("git rev-list failed: %w (%s)", err, out)
-	}
-	lines := strings.Fields(string(out))
-	return lines, nil
-}
-
-func gitShowCommitDiff(commit string) (string, error) {
-	cmd := exec.Command("git", "show", "--pretty=", "--unified=0", commit)
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("git show %s failed: %w", commit, err)
-	}
-	return string(out), nil
-}
-
-func scanGitHistory(rules map[string]*Rule, config *Config, stats *Stats) ([]Finding, error) {
-	if !gitAvailable() {
-		return nil, errors.New("git not available in PATH")
-	}
-	commits, err := gitAllCommits()
-	if err != nil {
-		return nil, err
-	}
-
-	var results []Finding
-	for _, c := range commits {
-		diff, err := gitShowCommitDiff(c)
-		if err != nil {
-			// skip commits that fail
-			continue
-		}
-
-		stats.incrementCommits()
-
-		s := bufio.NewScanner(strings.NewReader(diff))
-		ln := 0
-		currentFile := ""
-		skipCurrentFile := false
-
-		for s.Scan() {
-			ln++
-			line := s.Text()
-
-			// Track which file we're currently processing in the diff
-			// Git diff format: "diff --git a/path/to/file b/path/to/file"
-			if strings.HasPrefix(line, "diff --git") {
-				// Extract filename from diff header
-				parts := strings.Fields(line)
-				if len(parts) >= 4 {
-					// The filename is in parts[2] (a/filename) or parts[3] (b/filename)
-					// We'll use parts[3] (b/filename) as it represents the new version
-					currentFile = strings.TrimPrefix(parts[3], "b/")
-					skipCurrentFile = shouldSkipFile(currentFile)
-
-					if config.Verbose && skipCurrentFile {
-						fmt.Printf("Skipping file in git history: %s (commit: %s)\n", currentFile, c[:8])
-					}
-				}
-				continue
-			}
-
-			// Skip content if we're in a file that should be skipped
-			if skipCurrentFile {
-				continue
-			}
-
-			// Only scan added or removed lines
-			if !strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "-") {
-				continue
-			}
-
-			// Check regex patterns
-			for name, rule := range rules {
-				if !rule.Enabled {
-					continue
-				}
-
-				if loc := rule.Pattern.FindStringIndex(line); loc != nil {
-					rawValue := line[loc[0]:loc[1]]
-
-					// Check if allowed
-					if isAllowed(rawValue, config.AllowPatterns) {
-						continue
-					}
-
-					results = append(results, Finding{
-						File:       "(git-history)",
-						Line:       ln,
-						Commit:     c,
-						Pattern:    name,
-						Excerpt:    maskSecret(stringExcerpt(line, loc[0], loc[1])),
-						RawValue:   rawValue,
-						Confidence: 0.85,
-						Verified:   false,
-						Hash:       generateHash(c, name, rawValue),
-					})
-				}
-			}
-
-			// Check entropy (with stricter threshold for git history)
-			if config.EntropyThreshold > 0 {
-				toks := regexp.MustCompile(`\S{20,}`).FindAllString(line, -1)
-				for _, t := range toks {
-					// Skip if allowed
-					if isAllowed(t, config.AllowPatterns) {
-						continue
-					}
-
-					// Use higher threshold for git history to reduce noise
-					if isHighEntropy(t, config.EntropyThreshold+0.5) {
-						results = append(results, Finding{
-							File:       "(git-history)",
-							Line:       ln,
-							Commit:     c,
-							Pattern:    "high_entropy",
-							Excerpt:    maskSecret(t),
-							RawValue:   t,
-							Confidence: 0.55,
-							Verified:   false,
-							Hash:       generateHash(c, "high_entropy", t),
-						})
-					}
-				}
-			}
-		}
-	}
-	return results, nil
-}
-
-// deduplicateFindings removes duplicate findings based on hash
-func deduplicateFindings(findings []Finding) []Finding {
-	seen := make(map[string]bool)
-	var unique []Finding
-
-	for _, f := range findings {
-		if !seen[f.Hash] {
-			seen[f.Hash] = true
-			unique = append(unique, f)
-		}
-	}
-
-	return unique
-}
-
-func printFindings(findings []Finding, verbose bool) {
+func printFindings(findings []scanner.Finding, verbose bool) {
 	if len(findings) == 0 {
 		fmt.Println("✅ No secrets found")
 		return
 	}
 
 	// Sort by file, then line
-	sort.Slice(findings, func(i, j int) bool {
-		if findings[i].File == findings[j].File {
-			return findings[i].Line < findings[j].Line
-		}
-		return findings[i].File < findings[j].File
-	})
+	scanner.SortFindings(findings)
 
 	// Group by severity
-	critical := 0
-	high := 0
-	medium := 0
-	low := 0
-
-	for _, f := range findings {
-		switch {
-		case f.Confidence >= 0.9:
-			critical++
-		case f.Confidence >= 0.8:
-			high++
-		case f.Confidence >= 0.6:
-			medium++
-		default:
-			low++
-		}
-	}
+	counts := scanner.CountSeverities(findings)
 
 	fmt.Println("\n🔍 Secret Scan Results")
 	fmt.Println("=" + strings.Repeat("=", 50))
 	fmt.Printf("Total findings: %d\n", len(findings))
-	fmt.Printf("  Critical (≥0.9): %d\n", critical)
-	fmt.Printf("  High (≥0.8):     %d\n", high)
-	fmt.Printf("  Medium (≥0.6):   %d\n", medium)
-	fmt.Printf("  Low (<0.6):      %d\n", low)
+	fmt.Printf("  Critical: %d\n", counts.Critical)
+	fmt.Printf("  High:     %d\n", counts.High)
+	fmt.Printf("  Medium:   %d\n", counts.Medium)
+	fmt.Printf("  Low:      %d\n", counts.Low)
 	fmt.Println("=" + strings.Repeat("=", 50))
 
 	if !verbose && len(findings) > 100 {
@@ -889,44 +70,75 @@ func printFindings(findings []Finding, verbose bool) {
 	}
 
 	for _, f := range findings {
-		// Color coding based on confidence
+		// Color coding based on severity
 		var prefix string
-		switch {
-		case f.Confidence >= 0.9:
+		switch f.Severity {
+		case scanner.SeverityCritical:
 			prefix = "🔴 [CRITICAL]"
-		case f.Confidence >= 0.8:
+		case scanner.SeverityHigh:
 			prefix = "🟠 [HIGH]"
-		case f.Confidence >= 0.6:
+		case scanner.SeverityMedium:
 			prefix = "🟡 [MEDIUM]"
 		default:
 			prefix = "⚪ [LOW]"
 		}
 
-		fmt.Printf("%s [%s] %s:%d", prefix, strings.ToUpper(f.Pattern), f.File, f.Line)
+		fmt.Printf("%s [%s] %s", prefix, strings.ToUpper(f.Pattern), scanner.FindingLocation(f))
 		if f.Commit != "" {
 			fmt.Printf(" (commit %s)", f.Commit[:8])
 		}
-		fmt.Printf("\n  → %s (confidence: %.2f)\n", f.Excerpt, f.Confidence)
+		fmt.Printf("\n  → %s (severity: %s, confidence: %.2f)\n", f.Excerpt, f.Severity, f.Confidence)
 
-		if verbose && f.Verified {
-			fmt.Println("  ✓ Verified")
+		if v := f.Verification; v != nil {
+			mark := map[string]string{scanner.VerificationVerified: "✓ Verified", scanner.VerificationInvalid: "✗ Invalid"}[v.Status]
+			if mark == "" {
+				mark = "? Unverified"
+			}
+			if v.Reason != "" {
+				mark += ": " + v.Reason
+			}
+			fmt.Println("  " + mark)
 		}
 		fmt.Println()
 	}
 }
 
-func loadRulesFromFile(path string) (map[string]string, error) {
-	// very small TOML-like parser: key = "regex" per line
+// fileConfig holds the settings read from a config file
+type fileConfig struct {
+	Rules        map[string]string
+	Severities   map[string]string
+	SourceTuning map[string]scanner.SourceTuning
+	RuleContexts map[string]scanner.RuleContext
+	VerifyURLs   map[string]string
+}
+
+func loadConfigFile(path string) (*fileConfig, error) {
+	// very small TOML-like parser: key = "regex" per line, with an optional
+	// [severity] section of rule = "level" lines, [source.<kind>] sections
+	// tuning detection for files, stdin, archives or history, [context.<rule>]
+	// sections setting the context a rule's matches need, and a [verify]
+	// section of provider = "base URL" lines
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	m := make(map[string]string)
+	cfg := &fileConfig{
+		Rules:        make(map[string]string),
+		Severities:   make(map[string]string),
+		SourceTuning: make(map[string]scanner.SourceTuning),
+		RuleContexts: make(map[string]scanner.RuleContext),
+		VerifyURLs:   make(map[string]string),
+	}
+	section := ""
 	for _, l := range strings.Split(string(b), "\n") {
 		line := strings.TrimSpace(l)
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
 			continue
@@ -934,25 +146,158 @@ func loadRulesFromFile(path string) (map[string]string, error) {
 		k := strings.TrimSpace(parts[0])
 		v := strings.TrimSpace(parts[1])
 		v = strings.Trim(v, " \"")
-		m[k] = v
+		switch section {
+		case "":
+			cfg.Rules[k] = v
+		case "severity":
+			cfg.Severities[k] = v
+		case "verify":
+			cfg.VerifyURLs[k] = v
+		default:
+			if rule := strings.TrimPrefix(section, "context."); rule != section {
+				c, ok := cfg.RuleContexts[rule]
+				if !ok {
+					c = scanner.DefaultRuleContexts()[rule]
+				}
+				if err := setRuleContext(&c, k, v); err != nil {
+					return nil, fmt.Errorf("[%s] %s: %w", section, k, err)
+				}
+				cfg.RuleContexts[rule] = c
+				continue
+			}
+			source := strings.TrimPrefix(section, "source.")
+			if source == section {
+				continue
+			}
+			t, ok := cfg.SourceTuning[source]
+			if !ok {
+				if t, ok = scanner.DefaultSourceTuning()[source]; !ok {
+					return nil, fmt.Errorf("unknown source [%s]", section)
+				}
+			}
+			if err := setSourceTuning(&t, k, v); err != nil {
+				return nil, fmt.Errorf("[%s] %s: %w", section, k, err)
+			}
+			cfg.SourceTuning[source] = t
+		}
+	}
+	return cfg, nil
+}
+
+// setSourceTuning applies one key of a [source.<kind>] config section
+func setSourceTuning(t *scanner.SourceTuning, key, value string) error {
+	var err error
+	switch key {
+	case "skip_comments":
+		t.SkipComments, err = strconv.ParseBool(value)
+	case "decode_blobs":
+		t.DecodeBlobs, err = strconv.ParseBool(value)
+	case "entropy_offset":
+		t.EntropyOffset, err = strconv.ParseFloat(value, 64)
+	case "entropy_confidence":
+		t.EntropyConfidence, err = strconv.ParseFloat(value, 64)
+	case "rule_confidence":
+		t.RuleConfidence, err = strconv.ParseFloat(value, 64)
+	default:
+		return fmt.Errorf("unknown setting")
+	}
+	return err
+}
+
+// setRuleContext applies one key of a [context.<rule>] config section
+func setRuleContext(c *scanner.RuleContext, key, value string) error {
+	var err error
+	switch key {
+	case "keywords":
+		c.Keywords = nil
+		for _, kw := range strings.Split(value, ",") {
+			if kw = strings.TrimSpace(kw); kw != "" {
+				c.Keywords = append(c.Keywords, kw)
+			}
+		}
+	case "names":
+		c.Names = value
+	case "chars":
+		c.Chars, err = strconv.Atoi(value)
+	case "lines":
+		c.Lines, err = strconv.Atoi(value)
+	case "confidence":
+		c.Confidence, err = strconv.ParseFloat(value, 64)
+	default:
+		return fmt.Errorf("unknown setting")
 	}
-	return m, nil
+	return err
 }
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		os.Exit(runReportCommand(os.Args[2:]))
+	}
+
+	// "secscan scan [flags] [paths|-]" is the same as "secscan [flags] [paths|-]"
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "scan" {
+		args = args[1:]
+	}
+
+	// "secscan image [flags] image..." scans container images instead of files
+	imageMode := len(args) > 0 && args[0] == "image"
+	if imageMode {
+		args = args[1:]
+	}
+
 	// Command line flags
 	root := flag.String("root", ".", "project root to scan")
 	history := flag.Bool("history", true, "scan git history (slower)")
 	jsonOut := flag.String("json", "", "path to write JSON report (optional)")
+	var formats, outputs stringList
+	flag.Var(&formats, "format", "report format, repeatable: text, json, ndjson, sarif, junit, csv, markdown, html (default text); ndjson sends key links and -verify results as update records")
+	flag.Var(&outputs, "output", "path for the report of the -format at the same position, repeatable (default: stdout)")
 	quiet := flag.Bool("quiet", false, "suppress human output (useful for CI)")
 	verbose := flag.Bool("verbose", false, "show detailed output with all findings")
 	configFile := flag.String("config", "", "path to custom config file (optional)")
 	entropyThreshold := flag.Float64("entropy", 5.0, "entropy threshold for detection (default 5.0)")
 	noEntropy := flag.Bool("no-entropy", false, "disable entropy-based detection")
+	decodeDepth := flag.Int("decode-depth", scanner.DefaultMaxDecodeDepth, "decode nested base64/hex blobs up to this depth and scan the decoded text (0 disables)")
+	scanArchives := flag.Bool("scan-archives", false, "scan inside zip, jar, whl, apk, tar and tar.gz archives")
+	archiveDepth := flag.Int("archive-depth", scanner.DefaultMaxArchiveDepth, "maximum nesting of archives within archives when -scan-archives is set")
+	archiveMaxSize := flag.Int64("archive-max-size", scanner.DefaultMaxArchiveSizeMB, "maximum uncompressed MB read from one archive when -scan-archives is set")
 	showVersion := flag.Bool("version", false, "show version information")
 	respectGitignore := flag.Bool("respect-gitignore", true, "respect .gitignore files when scanning (default: true)")
-
-	flag.Parse()
+	failOn := flag.String("fail-on", "low", "exit 1 only for findings at or above this severity: critical, high, medium, low or none")
+	minConfidence := flag.Float64("min-confidence", 0, "drop findings below this confidence (0.0-1.0) from output and exit code")
+	strict := flag.Bool("strict", false, "treat any scan error as fatal (exit 2)")
+	stdinName := flag.String("stdin-name", "<stdin>", "file name to report for content read from stdin ('-')")
+	filesFrom := flag.String("files-from", "", "scan the paths listed in this file ('-' for stdin) instead of walking -root")
+	nulSeparated := flag.Bool("0", false, "paths in -files-from are NUL-separated")
+	verify := flag.Bool("verify", false, "check found credentials with their provider's API (sends secrets over the network)")
+	verifyTimeout := flag.Duration("verify-timeout", scanner.DefaultVerifyTimeout, "timeout for each -verify request")
+	verifyRate := flag.Float64("verify-rate", scanner.DefaultVerifyRate, "maximum -verify requests per second to each provider")
+	timeout := flag.Duration("timeout", 0, "stop scanning after this long and report partial results (e.g. 10m; 0 for no limit)")
+
+	_ = flag.CommandLine.Parse(args)
+
+	// Explicit targets replace the -root walk; history is off unless asked for
+	targets := flag.Args()
+	if imageMode && len(targets) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: secscan image [flags] <image.tar|oci-layout-dir>...")
+		os.Exit(2)
+	}
+	explicitTargets := len(targets) > 0 || *filesFrom != ""
+	if explicitTargets && !flagSet("history") {
+		*history = false
+	}
+	readsStdin := *filesFrom == stdinTarget
+	for _, t := range targets {
+		if t == stdinTarget {
+			if readsStdin {
+				fmt.Fprintln(os.Stderr, "stdin can only be used once: as '-' or as -files-from -")
+				os.Exit(2)
+			}
+			readsStdin = true
+		}
+	}
 
 	if *showVersion {
 		fmt.Printf("secscan version %s\n", version)
@@ -961,69 +306,131 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Initialize stats
-	stats := &Stats{
-		StartTime: time.Now(),
+	reportOutputs, err := pairReportOutputs(formats, outputs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid report options: %v\n", err)
+		os.Exit(2)
+	}
+	if *jsonOut != "" {
+		reportOutputs = append(reportOutputs, reportOutput{Format: "json", Path: *jsonOut})
 	}
 
-	// Load rules
-	var rulesMap map[string]string
-	if *configFile != "" {
-		loaded, err := loadRulesFromFile(*configFile)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to load config file: %v\n", err)
-			fmt.Fprintf(os.Stderr, "Using default rules...\n")
-			rulesMap = defaultRegexps
-		} else {
-			rulesMap = loaded
+	// A machine-readable report on stdout must not be mixed with human output
+	for _, out := range reportOutputs {
+		if out.Path == "" {
+			*quiet = true
 		}
-	} else {
-		rulesMap = defaultRegexps
 	}
 
-	// Compile rules
-	compiled, err := compileRules(rulesMap)
+	failOnLevel, err := scanner.ParseSeverity(*failOn, true)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to compile rules: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Invalid -fail-on: %v\n", err)
 		os.Exit(2)
 	}
 
-	// Compile allow patterns
-	allowPatterns, err := compileAllowPatterns(defaultAllowPatterns)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to compile allow patterns: %v\n", err)
-		os.Exit(2)
+	// Load rules
+	var rulesMap, severities map[string]string
+	var sourceTuning map[string]scanner.SourceTuning
+	var ruleContexts map[string]scanner.RuleContext
+	var verifyURLs map[string]string
+	if *configFile != "" {
+		loaded, err := loadConfigFile(*configFile)
+		if err != nil {
+			// running without the user's rules and overrides would hide
+			// the mistake behind a scan that looks clean
+			fmt.Fprintf(os.Stderr, "Invalid config file: %v\n", err)
+			os.Exit(2)
+		}
+		if len(loaded.Rules) > 0 {
+			rulesMap = loaded.Rules
+		}
+		severities = loaded.Severities
+		sourceTuning = loaded.SourceTuning
+		ruleContexts = loaded.RuleContexts
+		verifyURLs = loaded.VerifyURLs
 	}
 
 	// Load gitignore patterns if enabled
-	var gitignorePatterns []GitignorePattern
+	var gitignorePatterns []scanner.GitignorePattern
 	if *respectGitignore {
-		gitignorePatterns = collectGitignorePatterns(*root)
+		gitignorePatterns = scanner.CollectGitignorePatterns(*root)
 		if !*quiet && len(gitignorePatterns) > 0 {
 			fmt.Printf("Loaded %d .gitignore patterns\n", len(gitignorePatterns))
 		}
 	}
 
-	// Create config
-	config := &Config{
-		Rules:             compiled,
-		AllowPatterns:     allowPatterns,
-		EntropyThreshold:  *entropyThreshold,
-		MinSecretLength:   8,
-		MaxSecretLength:   512,
-		Verbose:           *verbose,
-		RespectGitignore:  *respectGitignore,
-		GitignorePatterns: gitignorePatterns,
+	var allFindings []scanner.Finding
+
+	// Open streaming outputs before scanning so findings are written as they are found
+	var streams []*ndjsonStream
+	var batchOutputs []reportOutput
+	for _, out := range reportOutputs {
+		if out.Format != "ndjson" {
+			batchOutputs = append(batchOutputs, out)
+			continue
+		}
+		stream, err := openNDJSONStream(out.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open ndjson output: %v\n", err)
+			os.Exit(2)
+		}
+		streams = append(streams, stream)
+	}
+	emit := func(fnds []scanner.Finding) {
+		allFindings = append(allFindings, fnds...)
+		fnds = filterByConfidence(fnds, *minConfidence)
+		for _, s := range streams {
+			s.WriteFindings(fnds)
+		}
 	}
 
+	// Create the scanner
+	threshold := *entropyThreshold
 	if *noEntropy {
-		config.EntropyThreshold = 0
+		threshold = 0
+	}
+	opts := []scanner.Option{
+		scanner.WithSeverities(severities),
+		scanner.WithRuleContexts(ruleContexts),
+		scanner.WithEntropy(threshold),
+		scanner.WithDecodeDepth(*decodeDepth),
+		scanner.WithSourceTuning(sourceTuning),
+		scanner.WithGitignore(*respectGitignore, gitignorePatterns),
+		scanner.WithVerbose(*verbose),
+		scanner.WithFindingCallback(emit),
 	}
+	if rulesMap != nil {
+		opts = append(opts, scanner.WithRules(rulesMap))
+	}
+	if *scanArchives {
+		opts = append(opts, scanner.WithArchives(*archiveDepth, *archiveMaxSize<<20))
+	}
+	sc, err := scanner.New(opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+	stats := sc.Stats()
+	compiled := sc.Rules()
+
+	// Ctrl-C, SIGTERM or -timeout stop the scan; the reports below are still
+	// written and marked incomplete. A second signal kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
 	if !*quiet {
 		fmt.Println("SecScan v2.1.0 - Enhanced Secret Scanner")
 		fmt.Printf("Scanning: %s\n", *root)
-		fmt.Printf("Entropy threshold: %.1f\n", config.EntropyThreshold)
+		fmt.Printf("Entropy threshold: %.1f\n", threshold)
 		fmt.Printf("Rules loaded: %d\n", len(compiled))
 		if *respectGitignore {
 			fmt.Printf("Gitignore: enabled (%d patterns loaded)\n", len(gitignorePatterns))
@@ -1036,57 +443,96 @@ func main() {
 		fmt.Println()
 	}
 
-	var allFindings []Finding
-
 	// Scan files
-	_ = walkFiles(*root, config, func(path string) error {
-		fnds, err := scanFileForSecrets(path, compiled, config)
+	if !explicitTargets {
+		_, _ = sc.ScanPath(ctx, *root)
+	}
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		switch {
+		case imageMode:
+			_, _ = sc.ScanImage(ctx, t)
+		case t == stdinTarget:
+			_, _ = sc.ScanReader(ctx, *stdinName, os.Stdin)
+		default:
+			_, _ = sc.ScanPath(ctx, t)
+		}
+	}
+	if *filesFrom != "" {
+		paths, err := readPathListFile(*filesFrom, *nulSeparated)
 		if err != nil {
-			// ignore read errors on a file
-			return nil
+			stats.AddError(scanner.ScanError{Path: *filesFrom, Op: scanner.OpRead, Message: err.Error()})
 		}
-		if len(fnds) > 0 {
-			allFindings = append(allFindings, fnds...)
-			stats.incrementFindings(len(fnds))
+		for _, p := range paths {
+			if ctx.Err() != nil {
+				break
+			}
+			_, _ = sc.ScanPath(ctx, p)
 		}
-		stats.incrementFiles()
-		return nil
-	})
+	}
 
 	// Scan git history
-	if *history {
-		gh, err := scanGitHistory(compiled, config, stats)
-		if err == nil && len(gh) > 0 {
-			allFindings = append(allFindings, gh...)
-			stats.incrementFindings(len(gh))
-		} else if err != nil && !*quiet {
-			fmt.Fprintf(os.Stderr, "Warning: git history scan failed: %v\n", err)
+	if *history && ctx.Err() == nil {
+		if _, err := sc.ScanHistory(ctx, *root, scanner.HistoryOptions{}); err != nil && ctx.Err() == nil {
+			// history is scanned by default, so a tree outside git only
+			// leaves the scan incomplete when -history was asked for
+			if errors.Is(err, scanner.ErrNoHistory) && flagSet("history") {
+				stats.AddError(scanner.ScanError{Op: scanner.OpHistory, Message: err.Error()})
+			}
+			if !*quiet {
+				fmt.Fprintf(os.Stderr, "Warning: git history scan failed: %v\n", err)
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil && !*quiet {
+		reason := "interrupted"
+		if err == context.DeadlineExceeded {
+			reason = fmt.Sprintf("timed out after %s", *timeout)
 		}
+		fmt.Fprintf(os.Stderr, "Warning: scan %s; writing partial results\n", reason)
 	}
 
 	// Deduplicate findings
-	uniqueFindings := deduplicateFindings(allFindings)
+	uniqueFindings := filterByConfidence(scanner.DeduplicateFindings(allFindings), *minConfidence)
+	uniqueFindings = sc.LinkKeys(uniqueFindings)
+	if *verify {
+		uniqueFindings = scanner.VerifyFindings(ctx, uniqueFindings, scanner.VerifyOptions{
+			Verifiers: scanner.DefaultVerifiers(verifyURLs),
+			Timeout:   *verifyTimeout,
+			Rate:      *verifyRate,
+		})
+	}
 	stats.FindingsUnique = len(uniqueFindings)
 	stats.EndTime = time.Now()
 
-	// Write JSON output
-	if *jsonOut != "" {
-		output := map[string]interface{}{
-			"findings": uniqueFindings,
-			"stats": map[string]interface{}{
-				"files_scanned":    stats.FilesScanned,
-				"commits_scanned":  stats.CommitsScanned,
-				"findings_total":   stats.FindingsTotal,
-				"findings_unique":  stats.FindingsUnique,
-				"scan_duration_ms": stats.EndTime.Sub(stats.StartTime).Milliseconds(),
-			},
-			"version": "2.2.0",
+	// Write reports
+	report := newReport(uniqueFindings, stats, compiled, *root)
+	report.Settings = &ScanSettings{
+		Root:             *root,
+		ConfigFile:       *configFile,
+		History:          *history,
+		EntropyThreshold: threshold,
+		RespectGitignore: *respectGitignore,
+		FailOn:           failOnLevel,
+		MinConfidence:    *minConfidence,
+		Verify:           *verify,
+	}
+	for _, s := range streams {
+		if err := s.Finish(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write ndjson report: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	for _, out := range batchOutputs {
+		if err := writeReportOutput(out, report); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write %s report: %v\n", out.Format, err)
+			os.Exit(2)
 		}
-		b, _ := json.MarshalIndent(output, "", "  ")
-		_ = os.WriteFile(*jsonOut, b, 0644)
-
 		if !*quiet {
-			fmt.Printf("JSON report written to: %s\n\n", *jsonOut)
+			fmt.Printf("%s report written to: %s\n\n", strings.ToUpper(out.Format), out.Path)
 		}
 	}
 
@@ -1103,12 +549,48 @@ func main() {
 		fmt.Printf("Total findings:   %d\n", stats.FindingsTotal)
 		fmt.Printf("Unique findings:  %d\n", stats.FindingsUnique)
 		fmt.Printf("Scan duration:    %v\n", stats.EndTime.Sub(stats.StartTime).Round(time.Millisecond))
+		if len(stats.Errors) > 0 {
+			fmt.Printf("Scan errors:      %d\n", len(stats.Errors))
+		}
 		fmt.Println("=" + strings.Repeat("=", 50))
+
+		if len(stats.Errors) > 0 {
+			fmt.Println("\n⚠️  Scan Errors")
+			for _, e := range stats.Errors {
+				fmt.Printf("  - %s\n", e.Error())
+			}
+		}
 	}
 
-	// Exit with error code if secrets found
-	if len(uniqueFindings) > 0 {
-		os.Exit(1)
+	// Exit codes: 0 clean, 1 findings, 2 scan incomplete or error
+	os.Exit(exitCode(uniqueFindings, stats, failOnLevel, *strict))
+}
+
+// flagSet reports whether a flag was given explicitly on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// Process exit codes
+const (
+	exitClean      = 0
+	exitFindings   = 1
+	exitIncomplete = 2
+)
+
+// exitCode decides the process exit code for a finished scan
+func exitCode(findings []scanner.Finding, stats *scanner.Stats, failOn string, strict bool) int {
+	if stats.Incomplete() || (strict && len(stats.Errors) > 0) {
+		return exitIncomplete
+	}
+	if failsThreshold(findings, failOn) {
+		return exitFindings
 	}
-	os.Exit(0)
+	return exitClean
 }
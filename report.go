@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Zayan-Mohamed/secscan/scanner"
+)
+
+// Report is the data rendered by every report format
+type Report struct {
+	Findings []scanner.Finding
+	Stats    *scanner.Stats
+	Rules    map[string]*scanner.Rule
+	Root     string
+	Settings *ScanSettings
+}
+
+// ScanSettings records the options a scan ran with
+type ScanSettings struct {
+	Root             string  `json:"root"`
+	ConfigFile       string  `json:"config_file,omitempty"`
+	History          bool    `json:"history"`
+	EntropyThreshold float64 `json:"entropy_threshold"`
+	RespectGitignore bool    `json:"respect_gitignore"`
+	FailOn           string  `json:"fail_on"`
+	MinConfidence    float64 `json:"min_confidence"`
+	Verify           bool    `json:"verify,omitempty"`
+}
+
+// newReport builds a report with findings in a stable order
+func newReport(findings []scanner.Finding, stats *scanner.Stats, rules map[string]*scanner.Rule, root string) *Report {
+	sorted := make([]scanner.Finding, len(findings))
+	copy(sorted, findings)
+	scanner.SortFindings(sorted)
+	return &Report{
+		Findings: sorted,
+		Stats:    stats,
+		Rules:    rules,
+		Root:     root,
+	}
+}
+
+// errors returns the scan errors recorded in the report's stats
+func (r *Report) errors() []scanner.ScanError {
+	if r.Stats == nil {
+		return nil
+	}
+	return r.Stats.Errors
+}
+
+// complete reports whether the scan covered everything it was asked to; see
+// scanner.Stats.Incomplete
+func (r *Report) complete() bool {
+	return r.Stats == nil || !r.Stats.Incomplete()
+}
+
+// durationMillis returns the scan duration in milliseconds
+func (r *Report) durationMillis() int64 {
+	if r.Stats == nil {
+		return 0
+	}
+	return r.Stats.EndTime.Sub(r.Stats.StartTime).Milliseconds()
+}
+
+// ReportWriter renders a Report in a specific format
+type ReportWriter interface {
+	WriteReport(w io.Writer, r *Report) error
+}
+
+// ReportWriterFunc adapts a plain function to the ReportWriter interface
+type ReportWriterFunc func(w io.Writer, r *Report) error
+
+// WriteReport calls f(w, r)
+func (f ReportWriterFunc) WriteReport(w io.Writer, r *Report) error {
+	return f(w, r)
+}
+
+// reportWriters maps -format names to their writers
+var reportWriters = map[string]ReportWriter{
+	"json":     ReportWriterFunc(writeJSONReport),
+	"ndjson":   ReportWriterFunc(writeNDJSONReport),
+	"sarif":    ReportWriterFunc(writeSARIF),
+	"junit":    ReportWriterFunc(writeJUnitReport),
+	"csv":      ReportWriterFunc(writeCSVReport),
+	"markdown": ReportWriterFunc(writeMarkdownReport),
+	"html":     ReportWriterFunc(writeHTMLReport),
+}
+
+// reportFormatNames returns the registered format names in sorted order
+func reportFormatNames() []string {
+	names := make([]string, 0, len(reportWriters))
+	for name := range reportWriters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reportOutput pairs a report format with its destination ("" means stdout)
+type reportOutput struct {
+	Format string
+	Path   string
+}
+
+// pairReportOutputs matches each -format with the -output at the same position.
+// "text" is the human-readable terminal output and is not written by a ReportWriter.
+func pairReportOutputs(formats, outputs []string) ([]reportOutput, error) {
+	if len(outputs) > len(formats) {
+		return nil, fmt.Errorf("%d -output values given for %d -format values", len(outputs), len(formats))
+	}
+
+	var pairs []reportOutput
+	toStdout := 0
+	for i, format := range formats {
+		path := ""
+		if i < len(outputs) {
+			path = outputs[i]
+		}
+		if format == "text" {
+			if path != "" {
+				return nil, fmt.Errorf("-format text does not take an -output file")
+			}
+			continue
+		}
+		if _, ok := reportWriters[format]; !ok {
+			return nil, fmt.Errorf("unknown report format %q (available: text, %s)", format, strings.Join(reportFormatNames(), ", "))
+		}
+		if path == "" || path == "-" {
+			path = ""
+			toStdout++
+		}
+		pairs = append(pairs, reportOutput{Format: format, Path: path})
+	}
+
+	if toStdout > 1 {
+		return nil, fmt.Errorf("only one report format can be written to stdout")
+	}
+	return pairs, nil
+}
+
+// writeReportOutput renders a report to its destination file or stdout
+func writeReportOutput(out reportOutput, r *Report) error {
+	writer := reportWriters[out.Format]
+	if out.Path == "" {
+		return writer.WriteReport(os.Stdout, r)
+	}
+
+	f, err := os.Create(out.Path)
+	if err != nil {
+		return err
+	}
+	err = writer.WriteReport(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// stringList is a flag.Value collecting every occurrence of a repeatable flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// JUnit XML structures
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes findings as JUnit XML, one failing test case per finding.
+// A clean scan produces a single passing test case so CI test tabs show the run.
+func writeJUnitReport(w io.Writer, r *Report) error {
+	seconds := strconv.FormatFloat(float64(r.durationMillis())/1000, 'f', 3, 64)
+
+	var cases []junitTestCase
+	for _, f := range r.Findings {
+		location := fmt.Sprintf("%s:%d", f.File, f.Line)
+		text := fmt.Sprintf("%s\nconfidence: %.2f\nexcerpt: %s", location, f.Confidence, f.Excerpt)
+		if f.Commit != "" {
+			text += "\ncommit: " + f.Commit
+		}
+		cases = append(cases, junitTestCase{
+			Name:      fmt.Sprintf("%s %s", f.Pattern, location),
+			ClassName: f.File,
+			Failure: &junitFailure{
+				Message: fmt.Sprintf("%s secret detected: %s", strings.ToUpper(f.Severity), f.Pattern),
+				Type:    f.Pattern,
+				Text:    text,
+			},
+		})
+	}
+	if len(cases) == 0 {
+		cases = append(cases, junitTestCase{Name: "no secrets found", ClassName: "secscan"})
+	}
+
+	// Scan errors are reported as erroring test cases
+	scanErrors := r.errors()
+	for _, e := range scanErrors {
+		cases = append(cases, junitTestCase{
+			Name:      "scan error: " + e.Error(),
+			ClassName: "secscan.errors",
+			Error: &junitFailure{
+				Message: e.Message,
+				Type:    e.Op,
+				Text:    e.Error(),
+			},
+		})
+	}
+
+	suites := junitTestSuites{
+		Name:     "secscan",
+		Tests:    len(cases),
+		Failures: len(r.Findings),
+		Errors:   len(scanErrors),
+		Time:     seconds,
+		Suites: []junitTestSuite{{
+			Name:      "secscan",
+			Tests:     len(cases),
+			Failures:  len(r.Findings),
+			Errors:    len(scanErrors),
+			Time:      seconds,
+			TestCases: cases,
+		}},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeCSVReport writes one row per finding, then one per scan error, with a
+// header row. The "kind" column tells the two apart; error rows carry the
+// operation in "pattern" and the message in "excerpt".
+func writeCSVReport(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	header := []string{"kind", "file", "line", "commit", "pattern", "severity", "confidence", "verified", "excerpt", "hash"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, f := range r.Findings {
+		row := []string{
+			"finding",
+			f.File,
+			strconv.Itoa(f.Line),
+			f.Commit,
+			f.Pattern,
+			f.Severity,
+			strconv.FormatFloat(f.Confidence, 'f', 2, 64),
+			strconv.FormatBool(f.Verified),
+			f.Excerpt,
+			f.Hash,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	for _, e := range r.errors() {
+		row := []string{"error", e.Path, "", e.Commit, e.Op, "", "", "", e.Message, ""}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// markdownEscape makes a value safe for a Markdown table cell
+func markdownEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\n", " ")
+	s = strings.ReplaceAll(s, "`", "'")
+	return s
+}
+
+// writeMarkdownReport writes a summary table suitable for PR comments
+func writeMarkdownReport(w io.Writer, r *Report) error {
+	var b strings.Builder
+	counts := scanner.CountSeverities(r.Findings)
+
+	b.WriteString("## 🔍 SecScan Results\n\n")
+	if len(r.Findings) == 0 {
+		b.WriteString("✅ No secrets found\n")
+	} else {
+		fmt.Fprintf(&b, "**%d** potential secret(s) found.\n\n", len(r.Findings))
+		b.WriteString("| Severity | Count |\n")
+		b.WriteString("| --- | ---: |\n")
+		fmt.Fprintf(&b, "| 🔴 Critical | %d |\n", counts.Critical)
+		fmt.Fprintf(&b, "| 🟠 High | %d |\n", counts.High)
+		fmt.Fprintf(&b, "| 🟡 Medium | %d |\n", counts.Medium)
+		fmt.Fprintf(&b, "| ⚪ Low | %d |\n", counts.Low)
+
+		b.WriteString("\n| Severity | Rule | Location | Commit | Excerpt | Confidence |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | ---: |\n")
+		for _, f := range r.Findings {
+			commit := ""
+			if len(f.Commit) >= 8 {
+				commit = "`" + f.Commit[:8] + "`"
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | `%s:%d` | %s | `%s` | %.2f |\n",
+				strings.ToUpper(f.Severity),
+				f.Pattern,
+				markdownEscape(f.File), f.Line,
+				commit,
+				markdownEscape(f.Excerpt),
+				f.Confidence)
+		}
+	}
+
+	if scanErrors := r.errors(); len(scanErrors) > 0 {
+		fmt.Fprintf(&b, "\n### ⚠️ Scan Errors (%d)\n\n", len(scanErrors))
+		b.WriteString("The scan did not cover everything; results may be incomplete.\n\n")
+		b.WriteString("| Operation | Target | Message |\n")
+		b.WriteString("| --- | --- | --- |\n")
+		for _, e := range scanErrors {
+			target := e.Path
+			if e.Commit != "" {
+				target = e.Commit
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %s |\n", e.Op, markdownEscape(target), markdownEscape(e.Message))
+		}
+	}
+
+	if r.Stats != nil {
+		fmt.Fprintf(&b, "\n<sub>Scanned %d files and %d commits in %dms with secscan v%s</sub>\n",
+			r.Stats.FilesScanned, r.Stats.CommitsScanned, r.durationMillis(), version)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
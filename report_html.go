@@ -0,0 +1,390 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Zayan-Mohamed/secscan/scanner"
+)
+
+// htmlBreakdownRow is one row of a per-rule or per-file breakdown table
+type htmlBreakdownRow struct {
+	Name     string
+	Detail   string
+	Total    int
+	Critical int
+	High     int
+	Medium   int
+	Low      int
+}
+
+func (r *htmlBreakdownRow) add(severity string) {
+	r.Total++
+	switch severity {
+	case scanner.SeverityCritical:
+		r.Critical++
+	case scanner.SeverityHigh:
+		r.High++
+	case scanner.SeverityMedium:
+		r.Medium++
+	default:
+		r.Low++
+	}
+}
+
+// htmlFinding is a finding prepared for the HTML template
+type htmlFinding struct {
+	Severity   string
+	Rank       int
+	Rule       string
+	File       string
+	Line       int
+	Commit     string
+	ShortHash  string
+	Path       string
+	Excerpt    string
+	Confidence float64
+	Verified   bool
+}
+
+// htmlReportData is the view model rendered by htmlReportTemplate
+type htmlReportData struct {
+	Version     string
+	Root        string
+	GeneratedAt string
+	Duration    string
+	Stats       *scanner.Stats
+	Counts      scanner.SeverityCounts
+	Total       int
+	Rules       []htmlBreakdownRow
+	Files       []htmlBreakdownRow
+	Commits     []htmlBreakdownRow
+	Findings    []htmlFinding
+	Errors      []scanner.ScanError
+}
+
+// breakdownRows sorts breakdown rows by total findings, then name
+func breakdownRows(m map[string]*htmlBreakdownRow) []htmlBreakdownRow {
+	rows := make([]htmlBreakdownRow, 0, len(m))
+	for _, r := range m {
+		rows = append(rows, *r)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Total != rows[j].Total {
+			return rows[i].Total > rows[j].Total
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// buildHTMLReportData computes the summaries shown in the HTML report
+func buildHTMLReportData(r *Report) *htmlReportData {
+	data := &htmlReportData{
+		Version: version,
+		Root:    r.Root,
+		Stats:   r.Stats,
+		Counts:  scanner.CountSeverities(r.Findings),
+		Total:   len(r.Findings),
+		Errors:  r.errors(),
+	}
+	if r.Stats != nil {
+		data.GeneratedAt = r.Stats.EndTime.UTC().Format(time.RFC3339)
+		data.Duration = r.Stats.EndTime.Sub(r.Stats.StartTime).Round(time.Millisecond).String()
+	}
+
+	byRule := make(map[string]*htmlBreakdownRow)
+	byFile := make(map[string]*htmlBreakdownRow)
+	byCommit := make(map[string]*htmlBreakdownRow)
+
+	for _, f := range r.Findings {
+		severity := f.Severity
+
+		rule, ok := byRule[f.Pattern]
+		if !ok {
+			rule = &htmlBreakdownRow{Name: f.Pattern, Detail: scanner.RuleDescription(f.Pattern)}
+			if compiled, exists := r.Rules[f.Pattern]; exists {
+				rule.Detail = compiled.Description
+			}
+			byRule[f.Pattern] = rule
+		}
+		rule.add(severity)
+
+		file := f.File
+		if f.Commit != "" && f.Metadata["path"] != "" {
+			file = f.Metadata["path"]
+		}
+		fileRow, ok := byFile[file]
+		if !ok {
+			fileRow = &htmlBreakdownRow{Name: file}
+			byFile[file] = fileRow
+		}
+		fileRow.add(severity)
+
+		hf := htmlFinding{
+			Severity:   severity,
+			Rank:       scanner.SeverityRank(severity),
+			Rule:       f.Pattern,
+			File:       f.File,
+			Line:       f.Line,
+			Commit:     f.Commit,
+			Path:       f.Metadata["path"],
+			Excerpt:    f.Excerpt,
+			Confidence: f.Confidence,
+			Verified:   f.Verified,
+		}
+		if f.Commit != "" {
+			hf.ShortHash = f.Commit
+			if len(hf.ShortHash) > 8 {
+				hf.ShortHash = hf.ShortHash[:8]
+			}
+			commitRow, ok := byCommit[f.Commit]
+			if !ok {
+				commitRow = &htmlBreakdownRow{Name: f.Commit, Detail: hf.ShortHash}
+				byCommit[f.Commit] = commitRow
+			}
+			commitRow.add(severity)
+		}
+		data.Findings = append(data.Findings, hf)
+	}
+
+	data.Rules = breakdownRows(byRule)
+	data.Files = breakdownRows(byFile)
+	data.Commits = breakdownRows(byCommit)
+	return data
+}
+
+// writeHTMLReport writes a single self-contained HTML file with all styles
+// and scripts inlined so it can be opened offline from an email attachment
+func writeHTMLReport(w io.Writer, r *Report) error {
+	return htmlReportTemplate.Execute(w, buildHTMLReportData(r))
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"upper": strings.ToUpper,
+}).Parse(htmlReportSource))
+
+const htmlReportSource = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>SecScan Report{{if .Root}} – {{.Root}}{{end}}</title>
+<style>
+  :root { --critical:#c62828; --high:#ef6c00; --medium:#f9a825; --low:#78909c; --border:#dde1e6; --muted:#5f6b7a; }
+  * { box-sizing: border-box; }
+  body { margin: 0; font: 14px/1.5 -apple-system, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; color: #1f2933; background: #f5f7fa; }
+  header { background: #1f2933; color: #fff; padding: 20px 32px; }
+  header h1 { margin: 0 0 4px; font-size: 22px; }
+  header p { margin: 0; color: #cbd2d9; }
+  main { padding: 24px 32px; max-width: 1400px; margin: 0 auto; }
+  section { background: #fff; border: 1px solid var(--border); border-radius: 6px; padding: 16px 20px; margin-bottom: 24px; }
+  h2 { font-size: 17px; margin: 0 0 12px; }
+  .cards { display: flex; flex-wrap: wrap; gap: 12px; }
+  .card { flex: 1 1 140px; border-radius: 6px; padding: 12px 16px; color: #fff; }
+  .card .count { font-size: 28px; font-weight: 700; }
+  .card.total { background: #3e4c59; }
+  .card.critical, .badge.critical { background: var(--critical); }
+  .card.high, .badge.high { background: var(--high); }
+  .card.medium, .badge.medium { background: var(--medium); color: #1f2933; }
+  .card.low, .badge.low { background: var(--low); }
+  .meta { display: grid; grid-template-columns: max-content 1fr; gap: 4px 16px; margin: 0; }
+  .meta dt { color: var(--muted); }
+  .meta dd { margin: 0; }
+  table { width: 100%; border-collapse: collapse; }
+  th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid var(--border); vertical-align: top; }
+  th { background: #f0f3f7; user-select: none; }
+  th.sortable { cursor: pointer; }
+  th.sortable::after { content: " ⇅"; color: var(--muted); }
+  th.asc::after { content: " ▲"; }
+  th.desc::after { content: " ▼"; }
+  td.num, th.num { text-align: right; }
+  code { font-family: SFMono-Regular, Consolas, Menlo, monospace; font-size: 12px; word-break: break-all; }
+  .badge { display: inline-block; padding: 1px 8px; border-radius: 10px; color: #fff; font-size: 11px; font-weight: 600; }
+  .filters { display: flex; flex-wrap: wrap; gap: 12px; margin-bottom: 12px; align-items: center; }
+  .filters input[type=search] { flex: 1 1 260px; padding: 6px 10px; border: 1px solid var(--border); border-radius: 4px; }
+  .filters select { padding: 5px; }
+  .empty { color: var(--muted); }
+  .columns { display: grid; grid-template-columns: repeat(auto-fit, minmax(420px, 1fr)); gap: 24px; }
+  .columns section { margin-bottom: 0; }
+  footer { text-align: center; color: var(--muted); padding: 12px 0 24px; }
+</style>
+</head>
+<body>
+<header>
+  <h1>🔍 SecScan Report</h1>
+  <p>secscan v{{.Version}}{{if .GeneratedAt}} · generated {{.GeneratedAt}}{{end}}</p>
+</header>
+<main>
+  <section>
+    <h2>Summary</h2>
+    <div class="cards">
+      <div class="card total"><div class="count">{{.Total}}</div>Total findings</div>
+      <div class="card critical"><div class="count">{{.Counts.Critical}}</div>Critical</div>
+      <div class="card high"><div class="count">{{.Counts.High}}</div>High</div>
+      <div class="card medium"><div class="count">{{.Counts.Medium}}</div>Medium</div>
+      <div class="card low"><div class="count">{{.Counts.Low}}</div>Low</div>
+    </div>
+  </section>
+
+  <section>
+    <h2>Scan details</h2>
+    <dl class="meta">
+      {{if .Root}}<dt>Root</dt><dd><code>{{.Root}}</code></dd>{{end}}
+      {{with .Stats}}
+      <dt>Files scanned</dt><dd>{{.FilesScanned}}</dd>
+      <dt>Commits scanned</dt><dd>{{.CommitsScanned}}</dd>
+      <dt>Total findings</dt><dd>{{.FindingsTotal}}</dd>
+      <dt>Unique findings</dt><dd>{{.FindingsUnique}}</dd>
+      {{end}}
+      {{if .Duration}}<dt>Scan duration</dt><dd>{{.Duration}}</dd>{{end}}
+    </dl>
+  </section>
+
+  <div class="columns">
+    <section>
+      <h2>Findings by rule</h2>
+      {{if .Rules}}
+      <table class="sortable-table">
+        <thead><tr><th class="sortable">Rule</th><th class="sortable">Description</th><th class="sortable num">Total</th><th class="sortable num">Critical</th><th class="sortable num">High</th><th class="sortable num">Medium</th><th class="sortable num">Low</th></tr></thead>
+        <tbody>
+        {{range .Rules}}<tr><td><code>{{.Name}}</code></td><td>{{.Detail}}</td><td class="num">{{.Total}}</td><td class="num">{{.Critical}}</td><td class="num">{{.High}}</td><td class="num">{{.Medium}}</td><td class="num">{{.Low}}</td></tr>
+        {{end}}
+        </tbody>
+      </table>
+      {{else}}<p class="empty">✅ No secrets found</p>{{end}}
+    </section>
+
+    <section>
+      <h2>Findings by file</h2>
+      {{if .Files}}
+      <table class="sortable-table">
+        <thead><tr><th class="sortable">File</th><th class="sortable num">Total</th><th class="sortable num">Critical</th><th class="sortable num">High</th><th class="sortable num">Medium</th><th class="sortable num">Low</th></tr></thead>
+        <tbody>
+        {{range .Files}}<tr><td><code>{{.Name}}</code></td><td class="num">{{.Total}}</td><td class="num">{{.Critical}}</td><td class="num">{{.High}}</td><td class="num">{{.Medium}}</td><td class="num">{{.Low}}</td></tr>
+        {{end}}
+        </tbody>
+      </table>
+      {{else}}<p class="empty">✅ No secrets found</p>{{end}}
+    </section>
+  </div>
+
+  {{if .Errors}}
+  <section style="margin-top:24px">
+    <h2>⚠️ Scan errors ({{len .Errors}})</h2>
+    <p class="empty">The scan did not cover everything; results may be incomplete.</p>
+    <table class="sortable-table">
+      <thead><tr><th class="sortable">Operation</th><th class="sortable">Target</th><th>Message</th></tr></thead>
+      <tbody>
+      {{range .Errors}}<tr><td>{{.Op}}</td><td><code>{{if .Commit}}{{.Commit}}{{else}}{{.Path}}{{end}}</code></td><td>{{.Message}}</td></tr>
+      {{end}}
+      </tbody>
+    </table>
+  </section>
+  {{end}}
+
+  {{if .Commits}}
+  <section style="margin-top:24px">
+    <h2>Findings by commit</h2>
+    <table class="sortable-table">
+      <thead><tr><th class="sortable">Commit</th><th class="sortable num">Total</th><th class="sortable num">Critical</th><th class="sortable num">High</th><th class="sortable num">Medium</th><th class="sortable num">Low</th></tr></thead>
+      <tbody>
+      {{range .Commits}}<tr><td><code title="{{.Name}}">{{.Detail}}</code></td><td class="num">{{.Total}}</td><td class="num">{{.Critical}}</td><td class="num">{{.High}}</td><td class="num">{{.Medium}}</td><td class="num">{{.Low}}</td></tr>
+      {{end}}
+      </tbody>
+    </table>
+  </section>
+  {{end}}
+
+  <section style="margin-top:24px">
+    <h2>Findings</h2>
+    {{if .Findings}}
+    <div class="filters">
+      <input type="search" id="filter-text" placeholder="Filter by rule, file, commit or excerpt…">
+      <label>Severity
+        <select id="filter-severity">
+          <option value="">All</option>
+          <option value="critical">Critical</option>
+          <option value="high">High</option>
+          <option value="medium">Medium</option>
+          <option value="low">Low</option>
+        </select>
+      </label>
+      <span id="filter-count" class="empty"></span>
+    </div>
+    <table id="findings" class="sortable-table">
+      <thead><tr><th class="sortable">Severity</th><th class="sortable">Rule</th><th class="sortable">Location</th><th class="sortable num">Line</th><th class="sortable">Commit</th><th>Excerpt (masked)</th><th class="sortable num">Confidence</th></tr></thead>
+      <tbody>
+      {{range .Findings}}<tr data-severity="{{.Severity}}">
+        <td data-sort="{{.Rank}}"><span class="badge {{.Severity}}">{{upper .Severity}}</span>{{if .Verified}} ✓{{end}}</td>
+        <td><code>{{.Rule}}</code></td>
+        <td><code>{{.File}}</code>{{if .Path}}<br><code>{{.Path}}</code>{{end}}</td>
+        <td class="num">{{.Line}}</td>
+        <td>{{if .Commit}}<code title="{{.Commit}}">{{.ShortHash}}</code>{{end}}</td>
+        <td><code>{{.Excerpt}}</code></td>
+        <td class="num">{{printf "%.2f" .Confidence}}</td>
+      </tr>
+      {{end}}
+      </tbody>
+    </table>
+    {{else}}<p class="empty">✅ No secrets found</p>{{end}}
+  </section>
+</main>
+<footer>Generated by secscan v{{.Version}} · https://github.com/Zayan-Mohamed/secscan</footer>
+<script>
+(function () {
+  function cellValue(row, index) {
+    var cell = row.cells[index];
+    var v = cell.getAttribute("data-sort");
+    return v !== null ? v : cell.textContent.trim();
+  }
+
+  document.querySelectorAll("table.sortable-table").forEach(function (table) {
+    table.querySelectorAll("th.sortable").forEach(function (th, index) {
+      th.addEventListener("click", function () {
+        var asc = !th.classList.contains("asc");
+        table.querySelectorAll("th").forEach(function (h) { h.classList.remove("asc", "desc"); });
+        th.classList.add(asc ? "asc" : "desc");
+        var body = table.tBodies[0];
+        var rows = Array.prototype.slice.call(body.rows);
+        rows.sort(function (a, b) {
+          var x = cellValue(a, index), y = cellValue(b, index);
+          var nx = parseFloat(x), ny = parseFloat(y);
+          var cmp = (!isNaN(nx) && !isNaN(ny)) ? nx - ny : x.localeCompare(y);
+          return asc ? cmp : -cmp;
+        });
+        rows.forEach(function (r) { body.appendChild(r); });
+      });
+    });
+  });
+
+  var table = document.getElementById("findings");
+  if (!table) { return; }
+  var text = document.getElementById("filter-text");
+  var severity = document.getElementById("filter-severity");
+  var count = document.getElementById("filter-count");
+
+  function applyFilters() {
+    var q = text.value.toLowerCase();
+    var sev = severity.value;
+    var shown = 0;
+    Array.prototype.forEach.call(table.tBodies[0].rows, function (row) {
+      var visible = (!sev || row.getAttribute("data-severity") === sev) &&
+        (!q || row.textContent.toLowerCase().indexOf(q) !== -1);
+      row.style.display = visible ? "" : "none";
+      if (visible) { shown++; }
+    });
+    count.textContent = shown + " of " + table.tBodies[0].rows.length + " shown";
+  }
+
+  text.addEventListener("input", applyFilters);
+  severity.addEventListener("change", applyFilters);
+  applyFilters();
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,211 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Zayan-Mohamed/secscan/scanner"
+)
+
+// reportSchemaVersion is the version of the JSON report contract. Bump the
+// minor version for additive changes and the major version for breaking ones.
+const reportSchemaVersion = "1.4.0"
+
+// reportSchemaID is the published location of the JSON report schema
+const reportSchemaID = "https://raw.githubusercontent.com/Zayan-Mohamed/secscan/main/schema/report.schema.json"
+
+//go:embed schema/report.schema.json
+var reportSchema []byte
+
+// JSONReport is the typed JSON report described by schema/report.schema.json
+type JSONReport struct {
+	Schema        string              `json:"$schema"`
+	SchemaVersion string              `json:"schema_version"`
+	Version       string              `json:"version"`
+	Tool          JSONReportTool      `json:"tool"`
+	GeneratedAt   string              `json:"generated_at,omitempty"`
+	Complete      bool                `json:"complete"`
+	Config        *ScanSettings       `json:"config,omitempty"`
+	Rules         []JSONRule          `json:"rules"`
+	Findings      []scanner.Finding   `json:"findings"`
+	Errors        []scanner.ScanError `json:"errors"`
+	Stats         JSONReportStats     `json:"stats"`
+}
+
+// JSONReportTool identifies the scanner that produced a report
+type JSONReportTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+// JSONRule describes a rule that was loaded for the scan
+type JSONRule struct {
+	ID          string  `json:"id"`
+	Description string  `json:"description"`
+	Severity    string  `json:"severity"`
+	Confidence  float64 `json:"confidence"`
+	Enabled     bool    `json:"enabled"`
+}
+
+// JSONReportStats holds scan statistics
+type JSONReportStats struct {
+	FilesScanned   int            `json:"files_scanned"`
+	FilesSkipped   int            `json:"files_skipped"`
+	DirsSkipped    int            `json:"dirs_skipped"`
+	SkipReasons    map[string]int `json:"skip_reasons"`
+	CommitsScanned int            `json:"commits_scanned"`
+	FindingsTotal  int            `json:"findings_total"`
+	FindingsUnique int            `json:"findings_unique"`
+	DurationMillis int64          `json:"scan_duration_ms"`
+}
+
+// buildJSONReport converts a report into its typed JSON form
+func buildJSONReport(r *Report) *JSONReport {
+	out := &JSONReport{
+		Schema:        reportSchemaID,
+		SchemaVersion: reportSchemaVersion,
+		Version:       version,
+		Tool: JSONReportTool{
+			Name:    "secscan",
+			Version: version,
+			URL:     sarifInfoURI,
+		},
+		Config:   r.Settings,
+		Complete: r.complete(),
+		Rules:    []JSONRule{},
+		Findings: r.Findings,
+		Errors:   []scanner.ScanError{},
+		Stats: JSONReportStats{
+			SkipReasons:    map[string]int{},
+			DurationMillis: r.durationMillis(),
+		},
+	}
+	if out.Findings == nil {
+		out.Findings = []scanner.Finding{}
+	}
+
+	names := make([]string, 0, len(r.Rules))
+	for name := range r.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rule := r.Rules[name]
+		out.Rules = append(out.Rules, JSONRule{
+			ID:          name,
+			Description: rule.Description,
+			Severity:    rule.Severity,
+			Confidence:  rule.Confidence,
+			Enabled:     rule.Enabled,
+		})
+	}
+
+	if s := r.Stats; s != nil {
+		if !s.EndTime.IsZero() {
+			out.GeneratedAt = s.EndTime.UTC().Format(time.RFC3339)
+		}
+		out.Stats.FilesScanned = s.FilesScanned
+		out.Stats.FilesSkipped = s.FilesSkipped
+		out.Stats.DirsSkipped = s.DirsSkipped
+		out.Stats.CommitsScanned = s.CommitsScanned
+		out.Stats.FindingsTotal = s.FindingsTotal
+		out.Stats.FindingsUnique = s.FindingsUnique
+		out.Errors = append(out.Errors, s.Errors...)
+		for reason, n := range s.SkipReasons {
+			out.Stats.SkipReasons[reason] = n
+		}
+	}
+	return out
+}
+
+// writeJSONReport writes the versioned JSON report
+func writeJSONReport(w io.Writer, r *Report) error {
+	b, err := json.MarshalIndent(buildJSONReport(r), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// validateJSONReport checks a JSON report document against the embedded schema
+func validateJSONReport(data []byte) ([]string, error) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal(reportSchema, &schema); err != nil {
+		return nil, fmt.Errorf("embedded schema is invalid: %w", err)
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("not valid JSON: %w", err)
+	}
+
+	v := &schemaValidator{root: schema}
+	v.validate(schema, doc, "$")
+	return v.errors, nil
+}
+
+// runReportCommand implements the "secscan report" subcommands and returns the exit code
+func runReportCommand(args []string) int {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  secscan report validate <report.json>...  validate JSON reports against the schema")
+		fmt.Fprintln(os.Stderr, "  secscan report schema                     print the JSON report schema")
+	}
+
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	switch args[0] {
+	case "schema":
+		_, _ = os.Stdout.Write(reportSchema)
+		return 0
+	case "validate":
+		files := args[1:]
+		if len(files) == 0 {
+			usage()
+			return 2
+		}
+		status := 0
+		for _, path := range files {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+				status = 2
+				continue
+			}
+			problems, err := validateJSONReport(data)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+				if status == 0 {
+					status = 1
+				}
+				continue
+			}
+			if len(problems) == 0 {
+				fmt.Printf("%s: valid (schema_version %s)\n", path, reportSchemaVersion)
+				continue
+			}
+			fmt.Printf("%s: invalid\n", path)
+			for _, p := range problems {
+				fmt.Printf("  - %s\n", p)
+			}
+			if status == 0 {
+				status = 1
+			}
+		}
+		return status
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown report command: %s\n", args[0])
+		usage()
+		return 2
+	}
+}
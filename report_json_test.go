@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Zayan-Mohamed/secscan/scanner"
+)
+
+// TestJSONReportMatchesSchema verifies the JSON writer output validates against the published schema
+func TestJSONReportMatchesSchema(t *testing.T) {
+	for name, report := range map[string]*Report{
+		"populated": goldenReport(t),
+		"empty":     newReport(nil, &scanner.Stats{}, map[string]*scanner.Rule{}, "."),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeJSONReport(&buf, report); err != nil {
+				t.Fatal(err)
+			}
+			problems, err := validateJSONReport(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(problems) > 0 {
+				t.Errorf("report does not match schema:\n%s", strings.Join(problems, "\n"))
+			}
+		})
+	}
+}
+
+// TestValidateJSONReportRejectsInvalid verifies schema violations are reported
+func TestValidateJSONReportRejectsInvalid(t *testing.T) {
+	doc := `{
+  "$schema": "x",
+  "schema_version": "2.0.0",
+  "version": "2.2.2",
+  "tool": {"name": "secscan", "version": "2.2.2"},
+  "rules": [],
+  "findings": [{"file": "a.go", "line": -1, "pattern": "x", "excerpt": "", "confidence": 1.5, "verified": false, "hash": "h", "extra": true}],
+  "stats": {"files_scanned": 0}
+}`
+	problems, err := validateJSONReport([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"$.schema_version",
+		"$.findings[0].line",
+		"$.findings[0].confidence",
+		`unexpected property "extra"`,
+		`missing required property "commits_scanned"`,
+	}
+	joined := strings.Join(problems, "\n")
+	for _, w := range want {
+		if !strings.Contains(joined, w) {
+			t.Errorf("expected a problem mentioning %q, got:\n%s", w, joined)
+		}
+	}
+
+	if _, err := validateJSONReport([]byte("{not json")); err == nil {
+		t.Error("expected an error for malformed JSON")
+	}
+}
+
+// TestJSONReportComplete verifies that interrupted scans are marked incomplete
+func TestJSONReportComplete(t *testing.T) {
+	stats := &scanner.Stats{FilesScanned: 1}
+	if !buildJSONReport(newReport(nil, stats, map[string]*scanner.Rule{}, ".")).Complete {
+		t.Error("a finished scan should be complete")
+	}
+	stats.AddError(scanner.ScanError{Op: scanner.OpInterrupted, Message: context.Canceled.Error()})
+	if buildJSONReport(newReport(nil, stats, map[string]*scanner.Rule{}, ".")).Complete {
+		t.Error("an interrupted scan should not be complete")
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/Zayan-Mohamed/secscan/scanner"
+)
+
+// ndjsonFindingRecord is one "finding" line of an NDJSON stream
+type ndjsonFindingRecord struct {
+	Type string `json:"type"`
+	scanner.Finding
+}
+
+// ndjsonErrorRecord is an "error" line of an NDJSON stream
+type ndjsonErrorRecord struct {
+	Type string `json:"type"`
+	scanner.ScanError
+}
+
+// ndjsonUpdateRecord is an "update" line of an NDJSON stream. It replaces the
+// metadata and verification fields of the finding with the same hash once key
+// linking and verification, which run after the scan, have changed them.
+type ndjsonUpdateRecord struct {
+	Type         string                `json:"type"`
+	Hash         string                `json:"hash"`
+	Verified     bool                  `json:"verified"`
+	Verification *scanner.Verification `json:"verification,omitempty"`
+	Metadata     map[string]string     `json:"metadata,omitempty"`
+}
+
+// ndjsonStatsRecord is the final "stats" line of an NDJSON stream
+type ndjsonStatsRecord struct {
+	Type          string `json:"type"`
+	SchemaVersion string `json:"schema_version"`
+	Version       string `json:"version"`
+	Complete      bool   `json:"complete"`
+	JSONReportStats
+}
+
+// ndjsonStream writes one JSON object per finding as soon as it is found,
+// followed by a final stats record. Lines are written unbuffered so a killed
+// scan still leaves every finding produced so far.
+type ndjsonStream struct {
+	w      io.Writer
+	closer io.Closer
+	enc    *json.Encoder
+	seen   map[string]scanner.Finding // streamed findings by hash
+	err    error
+	mu     sync.Mutex
+}
+
+// newNDJSONStream starts a stream on w
+func newNDJSONStream(w io.Writer) *ndjsonStream {
+	return &ndjsonStream{
+		w:    w,
+		enc:  json.NewEncoder(w),
+		seen: make(map[string]scanner.Finding),
+	}
+}
+
+// openNDJSONStream starts a stream on a file, or stdout when path is empty
+func openNDJSONStream(path string) (*ndjsonStream, error) {
+	if path == "" {
+		return newNDJSONStream(os.Stdout), nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	s := newNDJSONStream(f)
+	s.closer = f
+	return s, nil
+}
+
+// WriteFindings emits findings not already streamed. The first write error is
+// kept and returned by Finish.
+func (s *ndjsonStream) WriteFindings(findings []scanner.Finding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range findings {
+		if s.err != nil {
+			return
+		}
+		if _, ok := s.seen[f.Hash]; ok {
+			continue
+		}
+		s.seen[f.Hash] = f
+		s.err = s.enc.Encode(ndjsonFindingRecord{Type: "finding", Finding: f})
+	}
+}
+
+// Finish writes an update for each streamed finding the report has changed,
+// the scan errors and the final stats record, then closes the destination
+func (s *ndjsonStream) Finish(r *Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range r.Findings {
+		if s.err != nil {
+			break
+		}
+		streamed, ok := s.seen[f.Hash]
+		if !ok || (f.Verification == streamed.Verification && reflect.DeepEqual(f.Metadata, streamed.Metadata)) {
+			continue
+		}
+		s.seen[f.Hash] = f
+		s.err = s.enc.Encode(ndjsonUpdateRecord{
+			Type:         "update",
+			Hash:         f.Hash,
+			Verified:     f.Verified,
+			Verification: f.Verification,
+			Metadata:     f.Metadata,
+		})
+	}
+	for _, e := range r.errors() {
+		if s.err != nil {
+			break
+		}
+		s.err = s.enc.Encode(ndjsonErrorRecord{Type: "error", ScanError: e})
+	}
+	if s.err == nil {
+		s.err = s.enc.Encode(ndjsonStatsRecord{
+			Type:            "stats",
+			SchemaVersion:   reportSchemaVersion,
+			Version:         version,
+			Complete:        r.complete(),
+			JSONReportStats: buildJSONReport(r).Stats,
+		})
+	}
+	if s.closer != nil {
+		if err := s.closer.Close(); s.err == nil {
+			s.err = err
+		}
+		s.closer = nil
+	}
+	return s.err
+}
+
+// writeNDJSONReport writes a completed report in NDJSON form. The CLI streams
+// instead; this writer serves callers that already hold the full report.
+func writeNDJSONReport(w io.Writer, r *Report) error {
+	s := newNDJSONStream(w)
+	s.WriteFindings(r.Findings)
+	return s.Finish(r)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Zayan-Mohamed/secscan/scanner"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/golden")
+
+// goldenReport returns a fixed report used by the golden-file tests
+func goldenReport(t *testing.T) *Report {
+	t.Helper()
+
+	defaults := scanner.DefaultRules()
+	rules, err := scanner.CompileRules(map[string]string{
+		"aws_access_key": defaults["aws_access_key"],
+		"generic_secret": defaults["generic_secret"],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2025, 12, 12, 10, 0, 0, 0, time.UTC)
+	stats := &scanner.Stats{
+		FilesScanned:   42,
+		CommitsScanned: 7,
+		FindingsTotal:  4,
+		FindingsUnique: 3,
+		FilesSkipped:   5,
+		DirsSkipped:    2,
+		SkipReasons:    map[string]int{scanner.SkipReasonFileType: 4, scanner.SkipReasonGitignore: 1, scanner.SkipReasonDirectory: 2},
+		Errors: []scanner.ScanError{
+			{Path: "secrets/locked.env", Op: scanner.OpRead, Message: "permission denied"},
+			{Commit: "b2c3d4e5f60718293a4b5c6d7e8f901234567890", Op: scanner.OpGitShow, Message: "exit status 128"},
+		},
+		StartTime: start,
+		EndTime:   start.Add(1500 * time.Millisecond),
+	}
+
+	findings := []scanner.Finding{
+		{
+			File:       "src/settings.py",
+			Line:       8,
+			Pattern:    "generic_secret",
+			Severity:   scanner.SeverityMedium,
+			Excerpt:    `pass****************"|x"`,
+			Confidence: 0.7,
+			Hash:       "5b0c9e2f4a1d7c3e",
+		},
+		{
+			File:       "config/aws.go",
+			Line:       14,
+			Pattern:    "aws_access_key",
+			Severity:   scanner.SeverityCritical,
+			Excerpt:    "AKIA************MPLE",
+			Confidence: 0.9,
+			Verified:   true,
+			Verification: &scanner.Verification{
+				Status: scanner.VerificationVerified,
+				Reason: "arn:aws:iam::123456789012:user/ci",
+			},
+			Hash: "0f1e2d3c4b5a6978",
+		},
+		{
+			File:       "(git-history)",
+			Line:       27,
+			Commit:     "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678",
+			Pattern:    "high_entropy",
+			Severity:   scanner.SeverityLow,
+			Excerpt:    "Zx9q************Lm4T",
+			Confidence: 0.55,
+			Metadata:   map[string]string{"path": "deploy/.env"},
+			Hash:       "9a8b7c6d5e4f3a2b",
+		},
+	}
+
+	report := newReport(findings, stats, rules, "")
+	report.Settings = &ScanSettings{
+		Root:             ".",
+		History:          true,
+		EntropyThreshold: 5.0,
+		RespectGitignore: true,
+		FailOn:           scanner.SeverityLow,
+	}
+	return report
+}
+
+// TestReportWritersGolden renders every registered format and compares it to testdata/golden
+func TestReportWritersGolden(t *testing.T) {
+	extensions := map[string]string{
+		"json":     "report.json",
+		"ndjson":   "report.ndjson",
+		"sarif":    "report.sarif",
+		"junit":    "report.junit.xml",
+		"csv":      "report.csv",
+		"markdown": "report.md",
+		"html":     "report.html",
+	}
+
+	for _, format := range reportFormatNames() {
+		t.Run(format, func(t *testing.T) {
+			name, ok := extensions[format]
+			if !ok {
+				t.Fatalf("no golden file registered for format %s", format)
+			}
+
+			var buf bytes.Buffer
+			if err := reportWriters[format].WriteReport(&buf, goldenReport(t)); err != nil {
+				t.Fatalf("WriteReport: %v", err)
+			}
+
+			path := filepath.Join("testdata", "golden", name)
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading golden file (run go test -update to create it): %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("%s output does not match %s\n--- got ---\n%s\n--- want ---\n%s", format, path, buf.String(), want)
+			}
+		})
+	}
+}
+
+// TestPairReportOutputs verifies -format/-output pairing rules
+func TestPairReportOutputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		formats []string
+		outputs []string
+		want    []reportOutput
+		wantErr bool
+	}{
+		{"text only", []string{"text"}, nil, nil, false},
+		{"paired", []string{"sarif", "csv"}, []string{"a.sarif", "b.csv"}, []reportOutput{{"sarif", "a.sarif"}, {"csv", "b.csv"}}, false},
+		{"trailing stdout", []string{"junit", "markdown"}, []string{"j.xml"}, []reportOutput{{"junit", "j.xml"}, {"markdown", ""}}, false},
+		{"unknown format", []string{"yaml"}, nil, nil, true},
+		{"two stdout", []string{"json", "csv"}, nil, nil, true},
+		{"too many outputs", []string{"json"}, []string{"a", "b"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pairReportOutputs(tt.formats, tt.outputs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("pair %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestNDJSONStreamDeduplicates verifies findings are streamed once per hash
+func TestNDJSONStreamDeduplicates(t *testing.T) {
+	var buf bytes.Buffer
+	stream := newNDJSONStream(&buf)
+
+	f := scanner.Finding{File: "a.go", Line: 1, Pattern: "github_pat", Hash: "h1"}
+	stream.WriteFindings([]scanner.Finding{f})
+	if got := bytes.Count(buf.Bytes(), []byte("\n")); got != 1 {
+		t.Fatalf("expected the finding to be written immediately, got %d lines", got)
+	}
+
+	stream.WriteFindings([]scanner.Finding{f, {File: "b.go", Line: 2, Pattern: "github_pat", Hash: "h2"}})
+	if err := stream.Finish(newReport(nil, &scanner.Stats{}, nil, "")); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("expected 2 findings and 1 stats record, got %d lines:\n%s", len(lines), buf.String())
+	}
+	if !bytes.Contains(lines[2], []byte(`"type":"stats"`)) {
+		t.Errorf("last record should be stats, got %s", lines[2])
+	}
+}
+
+// TestNDJSONStreamUpdates verifies that links and verification added after
+// the scan reach the stream as update records before the stats record
+func TestNDJSONStreamUpdates(t *testing.T) {
+	var buf bytes.Buffer
+	stream := newNDJSONStream(&buf)
+
+	key := scanner.Finding{File: "id_rsa", Line: 1, Pattern: "private_key", Hash: "h1", Metadata: map[string]string{"key_fingerprint": "SHA256:abc"}}
+	token := scanner.Finding{File: "a.go", Line: 1, Pattern: "github_pat", Hash: "h2"}
+	plain := scanner.Finding{File: "b.go", Line: 2, Pattern: "github_pat", Hash: "h3"}
+	stream.WriteFindings([]scanner.Finding{key, token, plain})
+
+	key.Metadata = map[string]string{"key_fingerprint": "SHA256:abc", "public_key_path": "id_rsa.pub"}
+	token.Verification = &scanner.Verification{Status: scanner.VerificationVerified}
+	if err := stream.Finish(newReport([]scanner.Finding{key, token, plain}, &scanner.Stats{}, nil, "")); err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	updates := make(map[string]ndjsonUpdateRecord)
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var rec ndjsonUpdateRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, rec.Type)
+		if rec.Type == "update" {
+			updates[rec.Hash] = rec
+		}
+	}
+	if want := "finding finding finding update update stats"; strings.Join(types, " ") != want {
+		t.Fatalf("records = %v, want %s", types, want)
+	}
+	if updates["h1"].Metadata["public_key_path"] != "id_rsa.pub" {
+		t.Errorf("key update = %+v, want the link", updates["h1"])
+	}
+	if v := updates["h2"].Verification; v == nil || v.Status != scanner.VerificationVerified {
+		t.Errorf("token update = %+v, want verified", updates["h2"])
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Zayan-Mohamed/secscan/scanner"
+)
+
+// SARIF 2.1.0 constants
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifInfoURI = "https://github.com/Zayan-Mohamed/secscan"
+
+	// sarifFingerprintKey names the partial fingerprint carrying Finding.Hash
+	sarifFingerprintKey = "secscanHash/v1"
+)
+
+// sarifLog is the top-level SARIF document
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool              `json:"tool"`
+	Invocations []sarifInvocation      `json:"invocations"`
+	Results     []sarifResult          `json:"results"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string                     `json:"name"`
+	Version        string                     `json:"version"`
+	InformationURI string                     `json:"informationUri"`
+	Rules          []sarifReportingDescriptor `json:"rules"`
+}
+
+type sarifReportingDescriptor struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	FullDescription      sarifMessage           `json:"fullDescription"`
+	DefaultConfiguration sarifConfiguration     `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(severity string) string {
+	switch severity {
+	case scanner.SeverityCritical, scanner.SeverityHigh:
+		return "error"
+	case scanner.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifSecuritySeverity maps a severity to the numeric "security-severity"
+// rule property code scanning dashboards use to rank alerts
+func sarifSecuritySeverity(severity string) string {
+	switch severity {
+	case scanner.SeverityCritical:
+		return "9.5"
+	case scanner.SeverityHigh:
+		return "8.0"
+	case scanner.SeverityMedium:
+		return "5.5"
+	default:
+		return "3.0"
+	}
+}
+
+// sarifURI returns a forward-slash path relative to the scan root
+func sarifURI(root, path string) string {
+	if root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// buildSARIF converts findings into a SARIF 2.1.0 log with one
+// reportingDescriptor per rule
+func buildSARIF(findings []scanner.Finding, rules map[string]*scanner.Rule, stats *scanner.Stats, root string) *sarifLog {
+	// Rules are emitted in sorted order so ruleIndex values are stable
+	names := make([]string, 0, len(rules)+len(scanner.DetectorNames()))
+	for name := range rules {
+		names = append(names, name)
+	}
+	for _, name := range scanner.DetectorNames() {
+		if _, ok := rules[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	index := make(map[string]int, len(names))
+	descriptors := make([]sarifReportingDescriptor, 0, len(names))
+	for i, name := range names {
+		index[name] = i
+		description := scanner.RuleDescription(name)
+		severity := scanner.RuleSeverity(name)
+		properties := map[string]interface{}{}
+		if rule, ok := rules[name]; ok {
+			description = rule.Description
+			severity = rule.Severity
+			properties["confidence"] = rule.Confidence
+		} else if name == "high_entropy" {
+			properties["confidence"] = 0.6
+		}
+		properties["severity"] = severity
+		properties["security-severity"] = sarifSecuritySeverity(severity)
+		descriptors = append(descriptors, sarifReportingDescriptor{
+			ID:                   name,
+			Name:                 name,
+			ShortDescription:     sarifMessage{Text: description},
+			FullDescription:      sarifMessage{Text: description},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(severity)},
+			Properties:           properties,
+		})
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		idx, ok := index[f.Pattern]
+		if !ok {
+			// Custom patterns reported without a rule entry get one appended
+			idx = len(descriptors)
+			index[f.Pattern] = idx
+			descriptors = append(descriptors, sarifReportingDescriptor{
+				ID:                   f.Pattern,
+				Name:                 f.Pattern,
+				ShortDescription:     sarifMessage{Text: f.Pattern},
+				FullDescription:      sarifMessage{Text: f.Pattern},
+				DefaultConfiguration: sarifConfiguration{Level: sarifLevel(f.Severity)},
+				Properties: map[string]interface{}{
+					"severity":          f.Severity,
+					"security-severity": sarifSecuritySeverity(f.Severity),
+				},
+			})
+		}
+
+		location := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: sarifURI(root, f.File)},
+		}
+		switch {
+		case f.Metadata["cell"] != "":
+			// Notebook lines are relative to a cell, which is in the properties
+		case f.Commit == "":
+			location.Region = &sarifRegion{StartLine: f.Line}
+		case f.Metadata["path"] != "":
+			// History lines are in the file as of the commit; removed lines
+			// are in its parent, so they get no region
+			location.ArtifactLocation.URI = filepath.ToSlash(f.Metadata["path"])
+			if f.Metadata["change"] != "removed" {
+				location.Region = &sarifRegion{StartLine: f.Line}
+			}
+		}
+
+		props := map[string]interface{}{
+			"severity":   f.Severity,
+			"confidence": f.Confidence,
+			"excerpt":    f.Excerpt,
+		}
+		if f.Commit != "" {
+			props["commit"] = f.Commit
+		}
+		if v := f.Verification; v != nil {
+			props["verification"] = v.Status
+			if v.Reason != "" {
+				props["verificationReason"] = v.Reason
+			}
+		}
+		for k, v := range f.Metadata {
+			if _, exists := props[k]; !exists {
+				props[k] = v
+			}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    f.Pattern,
+			RuleIndex: idx,
+			Level:     sarifLevel(f.Severity),
+			Message: sarifMessage{
+				Text: fmt.Sprintf("Potential secret (%s): %s", f.Pattern, f.Excerpt),
+			},
+			Locations:           []sarifLocation{{PhysicalLocation: location}},
+			PartialFingerprints: map[string]string{sarifFingerprintKey: f.Hash},
+			Properties:          props,
+		})
+	}
+
+	// Scan errors become tool execution notifications
+	invocation := sarifInvocation{ExecutionSuccessful: true}
+	if stats != nil {
+		for _, e := range stats.Errors {
+			n := sarifNotification{
+				Level:      "error",
+				Message:    sarifMessage{Text: e.Error()},
+				Properties: map[string]interface{}{"op": e.Op},
+			}
+			if e.Path != "" {
+				n.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifURI(root, e.Path)},
+				}}}
+			}
+			if e.Commit != "" {
+				n.Properties["commit"] = e.Commit
+			}
+			invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, n)
+		}
+		invocation.ExecutionSuccessful = len(stats.Errors) == 0
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "secscan",
+			Version:        version,
+			InformationURI: sarifInfoURI,
+			Rules:          descriptors,
+		}},
+		Invocations: []sarifInvocation{invocation},
+		Results:     results,
+	}
+	if stats != nil {
+		run.Properties = map[string]interface{}{
+			"filesScanned":   stats.FilesScanned,
+			"commitsScanned": stats.CommitsScanned,
+			"findingsTotal":  stats.FindingsTotal,
+			"findingsUnique": stats.FindingsUnique,
+		}
+	}
+
+	return &sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}
+}
+
+// writeSARIF writes the report as an indented SARIF 2.1.0 log
+func writeSARIF(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(buildSARIF(r.Findings, r.Rules, r.Stats, r.Root))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/Zayan-Mohamed/secscan/scanner"
+)
+
+// TestBuildSARIF verifies rule descriptors, locations and fingerprints
+func TestBuildSARIF(t *testing.T) {
+	rules, err := scanner.CompileRules(map[string]string{"aws_access_key": `AKIA[0-9A-Z]{16}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings := []scanner.Finding{
+		{File: "/repo/src/config.go", Line: 12, Pattern: "aws_access_key", Severity: scanner.SeverityCritical, Confidence: 0.9, Hash: "abc123"},
+		{File: "(git-history)", Line: 3, Commit: "0123456789abcdef", Pattern: "high_entropy", Severity: scanner.SeverityLow, Confidence: 0.55,
+			Hash: "def456", Metadata: map[string]string{"path": "old/secret.txt"}},
+	}
+
+	log := buildSARIF(findings, rules, &scanner.Stats{}, "/repo")
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF envelope: version=%s runs=%d", log.Version, len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	// the rule, then every built-in detector that is not a regex rule
+	if len(run.Tool.Driver.Rules) != 1+len(scanner.DetectorNames()) {
+		t.Fatalf("expected %d rule descriptors, got %d", 1+len(scanner.DetectorNames()), len(run.Tool.Driver.Rules))
+	}
+	if run.Tool.Driver.Rules[0].ID != "aws_access_key" || run.Tool.Driver.Rules[0].DefaultConfiguration.Level != "error" {
+		t.Errorf("unexpected descriptor: %+v", run.Tool.Driver.Rules[0])
+	}
+	for _, d := range run.Tool.Driver.Rules[1:] {
+		if d.ShortDescription.Text != scanner.RuleDescription(d.ID) || d.Properties["severity"] != scanner.RuleSeverity(d.ID) || d.Properties["security-severity"] == nil {
+			t.Errorf("detector descriptor: %+v", d)
+		}
+	}
+
+	first := run.Results[0]
+	if got := first.Locations[0].PhysicalLocation.ArtifactLocation.URI; got != "src/config.go" {
+		t.Errorf("uri = %s, want src/config.go", got)
+	}
+	if first.Locations[0].PhysicalLocation.Region == nil || first.Locations[0].PhysicalLocation.Region.StartLine != 12 {
+		t.Errorf("expected region with startLine 12")
+	}
+	if first.PartialFingerprints[sarifFingerprintKey] != "abc123" {
+		t.Errorf("fingerprint = %v, want abc123", first.PartialFingerprints)
+	}
+
+	second := run.Results[1]
+	if second.Level != "note" || second.RuleIndex != 1 {
+		t.Errorf("history result level=%s ruleIndex=%d", second.Level, second.RuleIndex)
+	}
+	if got := second.Locations[0].PhysicalLocation.ArtifactLocation.URI; got != "old/secret.txt" {
+		t.Errorf("history uri = %s, want old/secret.txt", got)
+	}
+	if second.Properties["commit"] != "0123456789abcdef" {
+		t.Errorf("commit property missing: %v", second.Properties)
+	}
+}
+
+// TestWriteSARIF verifies the encoded log is valid JSON
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSARIF(&buf, newReport(nil, nil, map[string]*scanner.Rule{}, ".")); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded["version"] != "2.1.0" {
+		t.Errorf("version = %v", decoded["version"])
+	}
+}
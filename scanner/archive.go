@@ -0,0 +1,243 @@
+package scanner
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Limits for archive scanning
+const (
+	// DefaultMaxArchiveDepth is how many archives-within-archives are opened
+	DefaultMaxArchiveDepth = 2
+
+	// DefaultMaxArchiveSizeMB caps the uncompressed bytes read from one archive
+	DefaultMaxArchiveSizeMB = 100
+
+	// binarySniffLength is how much of an entry is checked for NUL bytes
+	binarySniffLength = 512
+)
+
+// Archive formats
+const (
+	archiveZip   = "zip"
+	archiveTar   = "tar"
+	archiveTarGz = "tar.gz"
+	archiveGzip  = "gz"
+)
+
+// archiveSeparator joins an archive path and an entry path in finding locations
+const archiveSeparator = "!/"
+
+// archiveKind returns the archive format of a file or entry name, or ""
+func archiveKind(name string) string {
+	base := strings.ToLower(path.Base(filepath.ToSlash(name)))
+	switch {
+	case strings.HasSuffix(base, ".tar.gz"), strings.HasSuffix(base, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(base, ".tar"):
+		return archiveTar
+	case strings.HasSuffix(base, ".gz"):
+		return archiveGzip
+	}
+	switch path.Ext(base) {
+	case ".zip", ".jar", ".war", ".ear", ".whl", ".egg", ".apk", ".aar", ".nupkg":
+		return archiveZip
+	}
+	return ""
+}
+
+// archiveScanner streams the entries of one top-level archive, including
+// nested archives, through the text detectors
+type archiveScanner struct {
+	ctx      context.Context
+	rules    map[string]*Rule
+	config   *Config
+	stats    *Stats
+	budget   int64 // uncompressed bytes left for this archive
+	maxEntry int64 // largest single entry read, 0 for no limit beyond budget
+	findings []Finding
+}
+
+// scanArchiveFile scans the entries of an archive on disk. Entries are
+// reported as archive.zip!/inner/path. Problems with individual entries are
+// recorded in stats; the returned error is for the archive itself.
+func scanArchiveFile(ctx context.Context, name string, rules map[string]*Rule, config *Config, stats *Stats) ([]Finding, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &archiveScanner{
+		ctx:    ctx,
+		rules:  rules,
+		config: config,
+		stats:  stats,
+		budget: config.MaxArchiveSize,
+	}
+	err = s.scan(name, archiveKind(name), f, info.Size(), 0)
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return s.findings, err
+}
+
+// scan walks the entries of one archive
+func (s *archiveScanner) scan(name, kind string, r io.ReaderAt, size int64, depth int) error {
+	switch kind {
+	case archiveZip:
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return err
+		}
+		for _, f := range zr.File {
+			if err := s.ctx.Err(); err != nil {
+				return err
+			}
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				s.fail(name+archiveSeparator+f.Name, err)
+				continue
+			}
+			s.entry(name, f.Name, rc, int64(f.UncompressedSize64), depth)
+			rc.Close()
+		}
+		return nil
+
+	case archiveTar, archiveTarGz:
+		var in io.Reader = io.NewSectionReader(r, 0, size)
+		if kind == archiveTarGz {
+			gz, err := gzip.NewReader(in)
+			if err != nil {
+				return err
+			}
+			defer gz.Close()
+			in = gz
+		}
+		tr := tar.NewReader(in)
+		for {
+			if err := s.ctx.Err(); err != nil {
+				return err
+			}
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			s.entry(name, hdr.Name, tr, hdr.Size, depth)
+		}
+
+	case archiveGzip:
+		gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		inner := gz.Name
+		if inner == "" {
+			inner = strings.TrimSuffix(path.Base(filepath.ToSlash(name)), path.Ext(name))
+		}
+		s.entry(name, inner, gz, -1, depth)
+		return nil
+	}
+	return nil
+}
+
+// entry scans one archive member, opening it as an archive when it is one.
+// size is -1 when the archive format does not record it.
+func (s *archiveScanner) entry(archive, entryName string, r io.Reader, size int64, depth int) {
+	display := archive + archiveSeparator + strings.TrimPrefix(entryName, "/")
+
+	// Image layers reach here without -scan-archives; nested archives are then skipped
+	kind := archiveKind(entryName)
+	if kind != "" && !s.config.ScanArchives {
+		kind = ""
+	}
+	if kind == "" && !looksLikeTextFile(entryName) {
+		s.skipped(SkipReasonFileType)
+		return
+	}
+	if kind != "" && depth >= s.config.MaxArchiveDepth {
+		s.skipped(SkipReasonArchiveLimit)
+		return
+	}
+
+	limit := s.budget
+	if s.maxEntry > 0 && s.maxEntry < limit {
+		limit = s.maxEntry
+	}
+	if size > limit {
+		s.skipped(SkipReasonArchiveLimit)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(contextReader{ctx: s.ctx, r: r}, limit+1))
+	if err != nil {
+		s.fail(display, err)
+		return
+	}
+	if int64(len(data)) > limit {
+		s.skipped(SkipReasonArchiveLimit)
+		return
+	}
+	s.budget -= int64(len(data))
+
+	if kind != "" {
+		if err := s.scan(display, kind, bytes.NewReader(data), int64(len(data)), depth+1); err != nil {
+			s.fail(display, err)
+		}
+		return
+	}
+
+	sniff := data
+	if len(sniff) > binarySniffLength {
+		sniff = sniff[:binarySniffLength]
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		s.skipped(SkipReasonFileType)
+		return
+	}
+
+	findings, err := scanReaderForSecrets(s.ctx, SourceArchive, display, bytes.NewReader(data), s.rules, s.config)
+	if err != nil {
+		s.fail(display, err)
+	}
+	s.findings = append(s.findings, findings...)
+	if s.stats != nil {
+		s.stats.incrementFiles()
+	}
+}
+
+func (s *archiveScanner) skipped(reason string) {
+	if s.stats != nil {
+		s.stats.incrementSkipped(reason, false)
+	}
+}
+
+func (s *archiveScanner) fail(name string, err error) {
+	// Cancellation is reported once by the caller, not per entry
+	if s.stats != nil && s.ctx.Err() == nil {
+		s.stats.AddError(ScanError{Path: name, Op: OpArchive, Message: err.Error()})
+	}
+}
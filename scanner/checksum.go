@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+)
+
+// Confidence of a rule match after its embedded checksum or structure has
+// been checked offline
+const (
+	checksumValidConfidence   = 0.99
+	checksumInvalidConfidence = 0.3
+)
+
+// tokenValidator checks the structure a token embeds. ok reports whether the
+// value is well-formed; metadata describes what was found in it.
+type tokenValidator func(value string) (ok bool, metadata map[string]string)
+
+// checksumValidators validate the matches of rules whose tokens carry a
+// checksum or a parseable body, by rule name
+var checksumValidators = map[string]tokenValidator{
+	"github_pat":   validCRC32Token,
+	"github_oauth": validCRC32Token,
+	"github_app":   validCRC32Token,
+	"npm_token":    validCRC32Token,
+	"pypi_token":   validPyPIToken,
+}
+
+// validateChecksum records the result of the rule's validator, if it has one,
+// raising the confidence of well-formed tokens and demoting the others to low
+// severity unless [severity] overrides the rule
+func validateChecksum(rule *Rule, _ Chunk, c *Candidate) bool {
+	validate, ok := checksumValidators[rule.Name]
+	if !ok {
+		return true
+	}
+	valid, metadata := validate(c.Value)
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string, len(metadata)+1)
+	}
+	for k, v := range metadata {
+		c.Metadata[k] = v
+	}
+	if valid {
+		c.Metadata["checksum_valid"] = "true"
+		c.Confidence = checksumValidConfidence
+	} else {
+		c.Metadata["checksum_valid"] = "false"
+		c.Confidence = checksumInvalidConfidence
+		if !rule.severityOverridden() {
+			c.Severity = SeverityLow
+		}
+	}
+	return true
+}
+
+// base62Alphabet is the digit order used by GitHub-style token checksums
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// base62CRC32 returns the CRC32 of s as 6 base62 digits, zero padded
+func base62CRC32(s string) string {
+	n := crc32.ChecksumIEEE([]byte(s))
+	var digits [6]byte
+	for i := len(digits) - 1; i >= 0; i-- {
+		digits[i] = base62Alphabet[n%62]
+		n /= 62
+	}
+	return string(digits[:])
+}
+
+// validCRC32Token checks GitHub (ghp_, gho_, ghu_, ghs_) and npm (npm_)
+// tokens: after the prefix come 30 random characters and the base62 CRC32 of
+// those characters
+func validCRC32Token(value string) (bool, map[string]string) {
+	_, body, found := strings.Cut(value, "_")
+	if !found || len(body) != 36 {
+		return false, nil
+	}
+	return base62CRC32(body[:30]) == body[30:], nil
+}
+
+// Field types of the macaroon v2 binary format
+const (
+	macaroonEOS        = 0
+	macaroonLocation   = 1
+	macaroonIdentifier = 2
+	macaroonSignature  = 6
+)
+
+// validPyPIToken checks that a pypi- token is a v2 macaroon with a location,
+// an identifier and a 32-byte signature, and reports its location
+func validPyPIToken(value string) (bool, map[string]string) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimPrefix(value, "pypi-"), "="))
+	if err != nil || len(raw) == 0 || raw[0] != 2 {
+		return false, nil
+	}
+
+	var location, identifier, signature []byte
+	for p := raw[1:]; len(p) > 0; {
+		fieldType := p[0]
+		p = p[1:]
+		if fieldType == macaroonEOS {
+			continue
+		}
+		n, size := binary.Uvarint(p)
+		if size <= 0 || n > uint64(len(p)-size) {
+			return false, nil
+		}
+		data := p[size : size+int(n)]
+		p = p[size+int(n):]
+		switch {
+		case fieldType == macaroonLocation && location == nil:
+			location = data
+		case fieldType == macaroonIdentifier && identifier == nil:
+			identifier = data
+		case fieldType == macaroonSignature:
+			signature = data
+		}
+	}
+
+	if len(location) == 0 || len(identifier) == 0 || len(signature) != 32 {
+		return false, nil
+	}
+	return true, map[string]string{"macaroon_location": string(location)}
+}
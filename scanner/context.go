@@ -0,0 +1,246 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxContextLines bounds RuleContext.Lines, and so how many lines are kept
+// for looking back
+const maxContextLines = 20
+
+// RuleContext is text that must appear near a rule's match for the match to
+// be trusted, for rules such as heroku_api whose regex alone matches any
+// UUID. A match has context when a keyword or a name matching Names is within
+// Chars characters of it on its line, or on one of the Lines lines above or
+// below it.
+type RuleContext struct {
+	// Keywords count as context when found anywhere in the window, ignoring
+	// case
+	Keywords []string
+	// Names is a regex for variable names that count as context, such as
+	// `(?i)heroku_?api_?key`
+	Names string
+	// Chars is how many characters either side of the match are searched; 0
+	// searches the whole line
+	Chars int
+	// Lines is how many lines above and below the match are searched, at most
+	// 20
+	Lines int
+	// Confidence is given to matches without context; 0 drops them
+	Confidence float64
+
+	names *regexp.Regexp
+}
+
+// DefaultRuleContexts returns the context required by the built-in rules, by
+// rule name
+func DefaultRuleContexts() map[string]RuleContext {
+	out := make(map[string]RuleContext)
+	for _, r := range ruleCatalogue {
+		if r.Context != nil {
+			out[r.Name] = *r.Context
+		}
+	}
+	return out
+}
+
+// compileRuleContext returns a copy of c ready to match
+func compileRuleContext(c RuleContext) (*RuleContext, error) {
+	if c.Lines < 0 || c.Lines > maxContextLines {
+		return nil, fmt.Errorf("lines must be between 0 and %d", maxContextLines)
+	}
+	if c.Chars < 0 {
+		return nil, fmt.Errorf("chars must not be negative")
+	}
+	if c.Confidence < 0 || c.Confidence > 1 {
+		return nil, fmt.Errorf("confidence must be between 0 and 1")
+	}
+	if len(c.Keywords) == 0 && c.Names == "" {
+		return nil, fmt.Errorf("keywords or names are required")
+	}
+	if c.Names != "" {
+		re, err := regexp.Compile(c.Names)
+		if err != nil {
+			return nil, fmt.Errorf("names: %w", err)
+		}
+		c.names = re
+	}
+	return &c, nil
+}
+
+// applyRuleContexts sets the context of rules by name
+func applyRuleContexts(rules map[string]*Rule, contexts map[string]RuleContext) error {
+	for name, c := range contexts {
+		rule, ok := rules[name]
+		if !ok {
+			continue
+		}
+		compiled, err := compileRuleContext(c)
+		if err != nil {
+			return fmt.Errorf("context for %s: %w", name, err)
+		}
+		rule.Context = compiled
+	}
+	return nil
+}
+
+// near reports whether text holds a keyword or name. start and end locate the
+// match in text; with -1 the whole text is searched.
+func (c *RuleContext) near(text string, start, end int) bool {
+	if start >= 0 && c.Chars > 0 {
+		from, to := start-c.Chars, end+c.Chars
+		if from < 0 {
+			from = 0
+		}
+		if to > len(text) {
+			to = len(text)
+		}
+		text = text[from:to]
+	}
+	if containsAny(strings.ToLower(text), c.Keywords) {
+		return true
+	}
+	return c.names != nil && c.names.MatchString(text)
+}
+
+// missing applies the context's confidence to a match without context,
+// returning false when the match should be dropped
+func (c *RuleContext) missing(confidence *float64, metadata *map[string]string) bool {
+	if c.Confidence == 0 {
+		return false
+	}
+	*confidence = c.Confidence
+	setContextFound(metadata, false)
+	return true
+}
+
+// checkRuleContext looks for the rule's context around a candidate on its own
+// line. Candidates without it are settled here when the rule looks at no
+// other lines, and by contextLines otherwise.
+func checkRuleContext(rule *Rule, chunk Chunk, c *Candidate) bool {
+	ctx := rule.Context
+	if ctx.near(chunk.Text, c.Start, c.End) {
+		setContextFound(&c.Metadata, true)
+		return true
+	}
+	return ctx.Lines > 0 || ctx.missing(&c.Confidence, &c.Metadata)
+}
+
+func setContextFound(metadata *map[string]string, found bool) {
+	if *metadata == nil {
+		*metadata = make(map[string]string)
+	}
+	(*metadata)["context_found"] = fmt.Sprint(found)
+}
+
+// contextLines resolves the rule matches that found no context on their own
+// line against the lines around them. Findings are updated in place as
+// their window closes and the ones to drop are removed by finish.
+type contextLines struct {
+	rules   map[string]*Rule
+	recent  []Chunk // the lines before the current one, oldest first
+	pending []pendingContext
+	drop    map[int]bool
+	seen    int // findings already looked at
+}
+
+// pendingContext is a finding waiting on the lines below it
+type pendingContext struct {
+	index   int // in the findings passed to scan
+	context *RuleContext
+	left    int // lines still to look at
+}
+
+func newContextLines(rules map[string]*Rule) *contextLines {
+	return &contextLines{rules: rules, drop: make(map[int]bool)}
+}
+
+// scan feeds one chunk. findings holds the findings so far of the same input;
+// those added for chunk are checked against the lines above it.
+func (x *contextLines) scan(chunk Chunk, findings []Finding) {
+	if len(x.recent) > 0 && !followsChunk(x.recent[len(x.recent)-1], chunk) {
+		x.expire(findings)
+		x.recent = x.recent[:0]
+	}
+
+	kept := x.pending[:0]
+	for _, p := range x.pending {
+		if p.context.near(chunk.Text, -1, -1) {
+			setContextFound(&findings[p.index].Metadata, true)
+			continue
+		}
+		if p.left--; p.left == 0 {
+			x.resolveMissing(p, findings)
+			continue
+		}
+		kept = append(kept, p)
+	}
+	x.pending = kept
+
+	for i := x.seen; i < len(findings); i++ {
+		f := &findings[i]
+		rule := x.rules[f.Pattern]
+		if rule == nil || rule.Context == nil || rule.Context.Lines == 0 || f.Metadata["context_found"] == "true" {
+			continue
+		}
+		p := pendingContext{index: i, context: rule.Context, left: rule.Context.Lines}
+		if x.above(p.context) {
+			setContextFound(&f.Metadata, true)
+			continue
+		}
+		x.pending = append(x.pending, p)
+	}
+	x.seen = len(findings)
+
+	if len(x.recent) == maxContextLines {
+		x.recent = append(x.recent[:0], x.recent[1:]...)
+	}
+	x.recent = append(x.recent, chunk)
+}
+
+// above reports whether context holds in the lines before the current one
+func (x *contextLines) above(c *RuleContext) bool {
+	from := len(x.recent) - c.Lines
+	if from < 0 {
+		from = 0
+	}
+	for _, line := range x.recent[from:] {
+		if c.near(line.Text, -1, -1) {
+			return true
+		}
+	}
+	return false
+}
+
+// expire gives up on the pending findings
+func (x *contextLines) expire(findings []Finding) {
+	for _, p := range x.pending {
+		x.resolveMissing(p, findings)
+	}
+	x.pending = x.pending[:0]
+}
+
+func (x *contextLines) resolveMissing(p pendingContext, findings []Finding) {
+	f := &findings[p.index]
+	if !p.context.missing(&f.Confidence, &f.Metadata) {
+		x.drop[p.index] = true
+	}
+}
+
+// finish resolves the findings still pending at the end of the input and
+// returns findings without the dropped ones
+func (x *contextLines) finish(findings []Finding) []Finding {
+	x.expire(findings)
+	if len(x.drop) == 0 {
+		return findings
+	}
+	out := make([]Finding, 0, len(findings)-len(x.drop))
+	for i, f := range findings {
+		if !x.drop[i] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestRuleContext verifies that heroku_api and twilio_api matches need a
+// provider name on their line or the lines around them
+func TestRuleContext(t *testing.T) {
+	uuid := "2f4a6c8e-1b3d-4f5a-9c7e-" + "0a2b4c6d8e0f"
+	twilio := "SK" + "0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name       string
+		input      string
+		rule       string
+		line       int     // 0 when nothing is reported
+		confidence float64 // of the finding on line
+		found      string  // its context_found
+	}{
+		{"keyword on the line", "HEROKU_API_KEY=" + uuid, "heroku_api", 1, 0.9, "true"},
+		{"bare uuid", "request_id = " + uuid, "heroku_api", 0, 0, ""},
+		{"keyword too far along the line", "heroku: see the docs for details on how this build is set up, id " + uuid, "heroku_api", 0, 0, ""},
+		{"keyword on the line above", "[heroku]\napi_key = " + uuid, "heroku_api", 2, 0.9, "true"},
+		{"keyword on the line below", "api_key = " + uuid + "\n# heroku", "heroku_api", 1, 0.9, "true"},
+		{"keyword out of the window", "[heroku]\n\n\napi_key = " + uuid, "heroku_api", 0, 0, ""},
+		{"twilio name", "TWILIO_API_KEY=" + twilio, "twilio_api", 1, 0.9, "true"},
+		{"variable name", "API_KEY_SID=" + twilio, "twilio_api", 1, 0.9, "true"},
+		{"twilio without context", "key = " + twilio, "twilio_api", 1, 0.3, "false"},
+	}
+	s, err := New(WithEntropy(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := s.ScanReader(context.Background(), "app.conf", strings.NewReader(tt.input+"\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []Finding
+			for _, f := range findings {
+				if f.Pattern == tt.rule {
+					got = append(got, f)
+				}
+			}
+			if tt.line == 0 {
+				if len(got) != 0 {
+					t.Errorf("reported %+v", got)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d findings, want 1", len(got))
+			}
+			if f := got[0]; f.Line != tt.line || f.Confidence != tt.confidence || f.Metadata["context_found"] != tt.found {
+				t.Errorf("line %d, confidence %.2f, metadata %v", f.Line, f.Confidence, f.Metadata)
+			}
+		})
+	}
+}
+
+// TestWithRuleContexts verifies that a rule's context can be replaced and
+// set for a custom rule
+func TestWithRuleContexts(t *testing.T) {
+	uuid := "2f4a6c8e-1b3d-4f5a-9c7e-" + "0a2b4c6d8e0f"
+	s, err := New(
+		WithRules(map[string]string{"heroku_api": defaultRegexps["heroku_api"], "build_token": `bt_[0-9a-f]{16}`}),
+		WithEntropy(0),
+		WithRuleContexts(map[string]RuleContext{
+			"heroku_api":  {Keywords: []string{"heroku"}, Lines: 3, Confidence: 0.2},
+			"build_token": {Names: `(?i)\bbuild_?token\b`},
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "[heroku]\n\n\napi_key = " + uuid + "\nother = " + uuid + "\nBUILD_TOKEN=bt_" + "0123456789abcdef\nid=bt_" + "0123456789abcdef\n"
+	findings, err := s.ScanReader(context.Background(), "app.conf", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Pattern+":"+f.Metadata["context_found"])
+	}
+	want := "heroku_api:true heroku_api:false build_token:true"
+	if strings.Join(got, " ") != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+
+	for _, bad := range []RuleContext{{Lines: 3}, {Keywords: []string{"x"}, Lines: 50}, {Names: "("}} {
+		if _, err := New(WithRuleContexts(map[string]RuleContext{"heroku_api": bad})); err == nil {
+			t.Errorf("expected an error for %+v", bad)
+		}
+	}
+}
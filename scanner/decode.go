@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits for the decoding layer
+const (
+	// DefaultMaxDecodeDepth is how many nested encodings are unwrapped by default
+	DefaultMaxDecodeDepth = 2
+
+	minBase64BlobLength = 24
+	minHexBlobLength    = 32
+	maxEncodedBlobSize  = 1 << 20
+
+	// minDecodedPrintable is the share of printable runes decoded text must have
+	minDecodedPrintable = 0.95
+)
+
+// encodedBlobPattern finds candidate base64, base64url and hex blobs. Hex is a
+// subset of the base64 alphabet, so one pattern covers all three.
+var encodedBlobPattern = regexp.MustCompile(`[A-Za-z0-9+/_\-]{24,}={0,2}`)
+
+// isHexString reports whether s consists only of hex digits
+func isHexString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// looksLikeText reports whether decoded bytes are printable text worth scanning
+func looksLikeText(b []byte) bool {
+	if len(b) < 8 || !utf8.Valid(b) {
+		return false
+	}
+	total, printable := 0, 0
+	for _, r := range string(b) {
+		total++
+		if unicode.IsPrint(r) || r == '\n' || r == '\r' || r == '\t' {
+			printable++
+		}
+	}
+	return float64(printable)/float64(total) >= minDecodedPrintable
+}
+
+// decodeBlob tries to decode a hex, base64 or base64url blob into text.
+// It returns the decoded bytes and the name of the encoding that worked.
+func decodeBlob(blob string) ([]byte, string, bool) {
+	if len(blob) > maxEncodedBlobSize {
+		return nil, "", false
+	}
+
+	if len(blob) >= minHexBlobLength && len(blob)%2 == 0 && isHexString(blob) {
+		if b, err := hex.DecodeString(blob); err == nil && looksLikeText(b) {
+			return b, "hex", true
+		}
+		return nil, "", false
+	}
+
+	if len(blob) < minBase64BlobLength {
+		return nil, "", false
+	}
+
+	type attempt struct {
+		name string
+		enc  *base64.Encoding
+	}
+	var attempts []attempt
+	if strings.ContainsAny(blob, "-_") {
+		attempts = []attempt{{"base64url", base64.URLEncoding}, {"base64url", base64.RawURLEncoding}}
+	} else {
+		attempts = []attempt{{"base64", base64.StdEncoding}, {"base64", base64.RawStdEncoding}}
+	}
+
+	for _, a := range attempts {
+		b, err := a.enc.DecodeString(blob)
+		if err != nil {
+			continue
+		}
+		if looksLikeText(b) {
+			return b, a.name, true
+		}
+		return nil, "", false
+	}
+	return nil, "", false
+}
+
+// scanEncodedBlobs finds encoded blobs in a chunk, decodes them (recursively,
+// up to config.MaxDecodeDepth) and runs the rule set on the decoded text.
+// Findings are reported at the chunk of the outermost blob, with the encoding
+// chain and blob position recorded in Metadata.
+func scanEncodedBlobs(chunk Chunk, rules map[string]*Rule, config *Config) []Finding {
+	if config.MaxDecodeDepth <= 0 {
+		return nil
+	}
+
+	var findings []Finding
+	for _, loc := range encodedBlobPattern.FindAllStringIndex(chunk.Text, -1) {
+		blob := chunk.Text[loc[0]:loc[1]]
+		location := map[string]string{
+			"encoded_column":  strconv.Itoa(loc[0] + 1),
+			"encoded_length":  strconv.Itoa(len(blob)),
+			"encoded_excerpt": maskSecret(blob),
+		}
+		findings = append(findings, scanDecoded(chunk, blob, nil, 1, location, rules, config)...)
+	}
+	return findings
+}
+
+// scanDecoded decodes one blob and scans its text, recursing into nested blobs
+func scanDecoded(chunk Chunk, blob string, chain []string, depth int, location map[string]string, rules map[string]*Rule, config *Config) []Finding {
+	decoded, encoding, ok := decodeBlob(blob)
+	if !ok {
+		return nil
+	}
+
+	// Copy so sibling blobs do not share the chain's backing array
+	chain = append(append([]string(nil), chain...), encoding)
+
+	var findings []Finding
+	for _, text := range strings.Split(string(decoded), "\n") {
+		inner := chunk
+		inner.Text = text
+		for _, f := range runDetectors(inner, ruleDetectors(rules, config), config) {
+			if f.Metadata == nil {
+				f.Metadata = make(map[string]string)
+			}
+			f.Metadata["encoding"] = strings.Join(chain, ">")
+			f.Metadata["decode_depth"] = strconv.Itoa(depth)
+			for k, v := range location {
+				f.Metadata[k] = v
+			}
+			findings = append(findings, f)
+		}
+
+		if depth < config.MaxDecodeDepth {
+			for _, loc := range encodedBlobPattern.FindAllStringIndex(text, -1) {
+				findings = append(findings, scanDecoded(chunk, text[loc[0]:loc[1]], chain, depth+1, location, rules, config)...)
+			}
+		}
+	}
+	return findings
+}
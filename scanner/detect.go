@@ -0,0 +1,172 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// generateHash creates a unique hash for deduplication
+func generateHash(file, pattern, value string) string {
+	h := sha256.New()
+	h.Write([]byte(file + pattern + value))
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+func maskSecret(s string) string {
+	if len(s) <= 8 {
+		return strings.Repeat("*", len(s))
+	}
+	pref := s[:4]
+	suf := s[len(s)-4:]
+	return pref + strings.Repeat("*", len(s)-8) + suf
+}
+
+func stringExcerpt(line string, a, b int) string {
+	if a < 0 || b > len(line) || a >= b {
+		return strings.TrimSpace(line)
+	}
+	start := a - 20
+	if start < 0 {
+		start = 0
+	}
+	end := b + 20
+	if end > len(line) {
+		end = len(line)
+	}
+	return strings.TrimSpace(line[start:end])
+}
+
+// Improved entropy detection with adjustable threshold
+func isHighEntropy(s string, threshold float64) bool {
+	length := utf8.RuneCountInString(s)
+
+	// Must be at least 20 characters
+	if length < 20 {
+		return false
+	}
+
+	// Must have good character diversity
+	hasLower := false
+	hasUpper := false
+	hasDigit := false
+	hasSymbol := false
+
+	for _, r := range s {
+		if r >= 'a' && r <= 'z' {
+			hasLower = true
+		} else if r >= 'A' && r <= 'Z' {
+			hasUpper = true
+		} else if r >= '0' && r <= '9' {
+			hasDigit = true
+		} else {
+			hasSymbol = true
+		}
+	}
+
+	charClassCount := 0
+	for _, v := range []bool{hasLower, hasUpper, hasDigit, hasSymbol} {
+		if v {
+			charClassCount++
+		}
+	}
+
+	// Require at least 3 character classes
+	if charClassCount < 3 {
+		return false
+	}
+
+	// Calculate Shannon entropy
+	h := shannonEntropy(s)
+
+	// Check against threshold (default 5.0, increased from 4.0)
+	return h > threshold
+}
+
+func shannonEntropy(s string) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	e := 0.0
+	L := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / L
+		e += -p * math.Log2(p)
+	}
+	return e
+}
+
+func scanFileForSecrets(ctx context.Context, path string, rules map[string]*Rule, config *Config) ([]Finding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return scanReaderForSecrets(ctx, SourceFile, path, f, rules, config)
+}
+
+// contextReader fails reads once its context is done, so scanning a huge or
+// never-ending input can be interrupted
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// scanReaderForSecrets scans content from any reader, reporting findings
+// against name with the tuning of source. It backs file scanning as well as
+// stdin, file lists and archive entries. When ctx is done, reading stops and
+// the findings so far are returned with ctx's error.
+func scanReaderForSecrets(ctx context.Context, source, name string, in io.Reader, rules map[string]*Rule, config *Config) ([]Finding, error) {
+	in = contextReader{ctx: ctx, r: in}
+	format := structuredFormat(name)
+	notebook := isNotebookFile(name)
+	if format == "" && !notebook {
+		return scanLines(source, name, in, rules, config)
+	}
+
+	// Structured files and notebooks are read whole so they can be parsed
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	if notebook {
+		if findings, ok := scanNotebook(source, name, data, rules, config); ok {
+			return findings, nil
+		}
+		return scanLines(source, name, bytes.NewReader(data), rules, config)
+	}
+	findings, err := scanLines(source, name, bytes.NewReader(data), rules, config)
+	if err != nil {
+		return findings, err
+	}
+	return scanStructured(name, format, data, findings, config), nil
+}
+
+// scanLines runs content from a reader through the pipeline of source, one
+// line per chunk, parsing the key blocks it holds and checking rule context
+// across lines
+func scanLines(source, path string, in io.Reader, rules map[string]*Rule, config *Config) ([]Finding, error) {
+	p := newPipeline(source, rules, config)
+	err := lineChunks(path, in, func(c Chunk) {
+		p.scan(c)
+	})
+	return p.finish(), err
+}
@@ -0,0 +1,245 @@
+package scanner
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Detector finds secrets in chunks of text. The regex rules and the entropy
+// check are detectors; others can be added with WithDetectors.
+type Detector interface {
+	// Name is reported as the finding's Pattern and selects [severity] overrides
+	Name() string
+	// Keywords are matched case-insensitively against a chunk before Detect is
+	// called; a detector with no keywords sees every chunk
+	Keywords() []string
+	// Detect returns the possible secrets in a chunk
+	Detect(chunk Chunk) []Candidate
+}
+
+// Chunk is a piece of scanned text, usually one line, with its location
+type Chunk struct {
+	Path     string // file, archive entry or "(git-history)"
+	Line     int
+	Commit   string            // set for git history
+	Text     string            // the text to search
+	Metadata map[string]string // copied into every finding from this chunk
+}
+
+// Candidate is a possible secret reported by a Detector
+type Candidate struct {
+	Value string
+	// Start and End are Value's byte offsets in the chunk text, used to show
+	// surrounding context; leave both 0 when Value is not taken from the text
+	Start, End int
+	// Excerpt replaces the context shown (masked) for the value
+	Excerpt string
+	// Severity defaults to RuleSeverity of the detector name; [severity]
+	// overrides for the detector take precedence over it
+	Severity   string
+	Confidence float64
+	Metadata   map[string]string
+}
+
+// candidateProcessor refines a candidate of rule once its regex has matched in
+// chunk, such as by validating a checksum or decoding claims. It returns false
+// when the candidate should be dropped.
+type candidateProcessor func(rule *Rule, chunk Chunk, c *Candidate) bool
+
+// candidateProcessors are run on the candidates of the built-in rules whose
+// matches can be checked beyond their regex, by rule name
+var candidateProcessors = map[string]candidateProcessor{
+	"github_pat":   validateChecksum,
+	"github_oauth": validateChecksum,
+	"github_app":   validateChecksum,
+	"npm_token":    validateChecksum,
+	"pypi_token":   validateChecksum,
+	jwtRule:        classifyJWT,
+}
+
+// ruleDetector reports the first match of a regex rule in a chunk
+type ruleDetector struct {
+	rule       *Rule
+	confidence float64 // replaces the rule's confidence when set
+	processors []candidateProcessor
+}
+
+// newRuleDetector returns the detector of a rule with the processors its
+// candidates go through: the one registered for its name, then its context
+func newRuleDetector(rule *Rule, confidence float64) ruleDetector {
+	d := ruleDetector{rule: rule, confidence: confidence}
+	if p, ok := candidateProcessors[rule.Name]; ok {
+		d.processors = append(d.processors, p)
+	}
+	if rule.Context != nil {
+		d.processors = append(d.processors, checkRuleContext)
+	}
+	return d
+}
+
+func (d ruleDetector) Name() string       { return d.rule.Name }
+func (d ruleDetector) Keywords() []string { return d.rule.Keywords }
+
+func (d ruleDetector) Detect(chunk Chunk) []Candidate {
+	if !d.rule.Enabled {
+		return nil
+	}
+	loc := d.rule.Pattern.FindStringIndex(chunk.Text)
+	if loc == nil {
+		return nil
+	}
+	c := Candidate{
+		Value:      chunk.Text[loc[0]:loc[1]],
+		Start:      loc[0],
+		End:        loc[1],
+		Severity:   d.rule.Severity,
+		Confidence: d.rule.Confidence,
+	}
+	if d.confidence > 0 {
+		c.Confidence = d.confidence
+	}
+	for _, process := range d.processors {
+		if !process(d.rule, chunk, &c) {
+			return nil
+		}
+	}
+	return []Candidate{c}
+}
+
+// entropyTokenPattern splits text into tokens long enough for the entropy check
+var entropyTokenPattern = regexp.MustCompile(`\S{20,}`)
+
+// entropyDetector reports tokens whose Shannon entropy exceeds threshold
+type entropyDetector struct {
+	threshold  float64
+	severity   string
+	confidence float64
+}
+
+func (d entropyDetector) Name() string       { return "high_entropy" }
+func (d entropyDetector) Keywords() []string { return nil }
+
+func (d entropyDetector) Detect(chunk Chunk) []Candidate {
+	var out []Candidate
+	for _, tok := range entropyTokenPattern.FindAllString(chunk.Text, -1) {
+		if isHighEntropy(tok, d.threshold) {
+			out = append(out, Candidate{
+				Value:      tok,
+				Excerpt:    tok,
+				Severity:   d.severity,
+				Confidence: d.confidence,
+			})
+		}
+	}
+	return out
+}
+
+// ruleDetectors returns a detector for each rule, in name order, followed by
+// the custom detectors in config
+func ruleDetectors(rules map[string]*Rule, config *Config) []Detector {
+	return ruleDetectorsWithConfidence(rules, config, 0)
+}
+
+// ruleDetectorsWithConfidence is ruleDetectors with the confidence of every
+// rule match replaced when confidence is set
+func ruleDetectorsWithConfidence(rules map[string]*Rule, config *Config, confidence float64) []Detector {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]Detector, 0, len(names)+len(config.Detectors))
+	for _, name := range names {
+		out = append(out, newRuleDetector(rules[name], confidence))
+	}
+	return append(out, config.Detectors...)
+}
+
+// runDetectors runs detectors over a chunk and turns their candidates into
+// findings, dropping values that match an allow pattern
+func runDetectors(chunk Chunk, detectors []Detector, config *Config) []Finding {
+	var findings []Finding
+	lower := ""
+	for _, d := range detectors {
+		if kw := d.Keywords(); len(kw) > 0 {
+			if lower == "" {
+				lower = strings.ToLower(chunk.Text)
+			}
+			if !containsAny(lower, kw) {
+				continue
+			}
+		}
+		allow := config.AllowPatterns
+		if rd, ok := d.(ruleDetector); ok && rd.rule.SkipAllowPatterns {
+			allow = nil
+		}
+		for _, c := range d.Detect(chunk) {
+			if isAllowed(c.Value, allow) {
+				continue
+			}
+			findings = append(findings, candidateFinding(chunk, d.Name(), c, config))
+		}
+	}
+	return findings
+}
+
+// candidateFinding builds the finding for a detector's candidate
+func candidateFinding(chunk Chunk, name string, c Candidate, config *Config) Finding {
+	excerpt := c.Excerpt
+	if excerpt == "" {
+		excerpt = stringExcerpt(chunk.Text, c.Start, c.End)
+	}
+
+	severity := c.Severity
+	if s, ok := config.Severities[name]; ok {
+		severity = s
+	} else if severity == "" {
+		severity = RuleSeverity(name)
+	}
+
+	var metadata map[string]string
+	if len(chunk.Metadata)+len(c.Metadata) > 0 {
+		metadata = make(map[string]string, len(chunk.Metadata)+len(c.Metadata))
+		for k, v := range chunk.Metadata {
+			metadata[k] = v
+		}
+		for k, v := range c.Metadata {
+			metadata[k] = v
+		}
+	}
+
+	return Finding{
+		File:       chunk.Path,
+		Line:       chunk.Line,
+		Commit:     chunk.Commit,
+		Pattern:    name,
+		Severity:   severity,
+		Excerpt:    maskSecret(excerpt),
+		RawValue:   c.Value,
+		Confidence: c.Confidence,
+		Metadata:   metadata,
+		Hash:       generateHash(findingHashKey(chunk), name, c.Value),
+	}
+}
+
+// findingHashKey is the location findings in chunk are deduplicated by. History
+// findings are deduplicated per commit rather than per path.
+func findingHashKey(chunk Chunk) string {
+	if chunk.Commit != "" {
+		return chunk.Commit
+	}
+	return chunk.Path
+}
+
+// containsAny reports whether s contains any of the keywords, ignoring case.
+// s must already be lower case.
+func containsAny(s string, keywords []string) bool {
+	for _, kw := range keywords {
+		if strings.Contains(s, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return false
+}
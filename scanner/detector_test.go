@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// prefixDetector reports the word following "internal-token:" in a chunk
+type prefixDetector struct{ calls int }
+
+func (d *prefixDetector) Name() string       { return "internal_token" }
+func (d *prefixDetector) Keywords() []string { return []string{"INTERNAL-TOKEN"} }
+
+func (d *prefixDetector) Detect(chunk Chunk) []Candidate {
+	d.calls++
+	i := strings.Index(chunk.Text, "internal-token:")
+	if i < 0 {
+		return nil
+	}
+	start := i + len("internal-token:")
+	end := start + strings.IndexAny(chunk.Text[start:]+"\n", " \n")
+	return []Candidate{{
+		Value:      chunk.Text[start:end],
+		Start:      start,
+		End:        end,
+		Confidence: 0.95,
+		Metadata:   map[string]string{"kind": "internal"},
+	}}
+}
+
+// TestCustomDetector verifies that registered detectors run with keyword
+// prefiltering, allow patterns and severity overrides
+func TestCustomDetector(t *testing.T) {
+	d := &prefixDetector{}
+	s, err := New(
+		WithRules(map[string]string{}),
+		WithEntropy(0),
+		WithDetectors(d),
+		WithSeverities(map[string]string{"internal_token": "critical"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "nothing here\nauth = internal-token:Qm9vX2Zvb18xMjM0\nplaceholder internal-token:EXAMPLE_ONLY\n"
+	findings, err := s.ScanReader(context.Background(), "app.txt", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.calls != 2 {
+		t.Errorf("Detect called %d times, want 2 (keyword prefilter)", d.calls)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1 (all-caps value is allowed): %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.Pattern != "internal_token" || f.Line != 2 || f.RawValue != "Qm9vX2Zvb18xMjM0" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+	if f.Severity != SeverityCritical || f.Confidence != 0.95 || f.Metadata["kind"] != "internal" {
+		t.Errorf("severity, confidence or metadata not applied: %+v", f)
+	}
+}
+
+// TestRuleAndEntropyDetectors verifies the built-in detectors report the
+// same values as before they were detectors
+func TestRuleAndEntropyDetectors(t *testing.T) {
+	rules, err := CompileRules(map[string]string{"github_pat": defaultRegexps["github_pat"]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{EntropyThreshold: 4.0, EntropySeverity: SeverityLow}
+	chunk := Chunk{Path: "a.py", Line: 3, Text: `token = "` + checksumTestGitHub + `"`}
+
+	findings := newPipeline(SourceFile, rules, config).scan(chunk)
+	var patterns []string
+	for _, f := range findings {
+		patterns = append(patterns, f.Pattern)
+	}
+	if strings.Join(patterns, ",") != "github_pat,high_entropy" {
+		t.Fatalf("got patterns %v", patterns)
+	}
+	if findings[0].RawValue != checksumTestGitHub || findings[0].Severity != RuleSeverity("github_pat") {
+		t.Errorf("unexpected rule finding: %+v", findings[0])
+	}
+	if findings[1].Severity != SeverityLow || findings[1].Confidence != 0.6 {
+		t.Errorf("unexpected entropy finding: %+v", findings[1])
+	}
+}
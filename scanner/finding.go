@@ -0,0 +1,55 @@
+package scanner
+
+import "sort"
+
+// Finding represents a detected secret or potential secret
+type Finding struct {
+	File       string  `json:"file"`
+	Line       int     `json:"line"`
+	Commit     string  `json:"commit,omitempty"`
+	Pattern    string  `json:"pattern"`
+	Severity   string  `json:"severity"`
+	Excerpt    string  `json:"excerpt"`
+	RawValue   string  `json:"-"` // Not exported to JSON
+	Confidence float64 `json:"confidence"`
+	Verified   bool    `json:"verified"`
+	// Verification is set when the secret was checked with its provider
+	Verification *Verification     `json:"verification,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	Hash         string            `json:"hash"` // For deduplication
+}
+
+// Disproved reports whether an offline check shows the finding is not a
+// working secret, such as a token whose checksum does not match. Disproved
+// findings are reported but do not fail a scan.
+func (f Finding) Disproved() bool {
+	return f.Metadata["checksum_valid"] == "false"
+}
+
+// DeduplicateFindings removes duplicate findings based on hash
+func DeduplicateFindings(findings []Finding) []Finding {
+	seen := make(map[string]bool)
+	var unique []Finding
+
+	for _, f := range findings {
+		if !seen[f.Hash] {
+			seen[f.Hash] = true
+			unique = append(unique, f)
+		}
+	}
+
+	return unique
+}
+
+// SortFindings orders findings by file, line, then pattern
+func SortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Pattern < findings[j].Pattern
+	})
+}
@@ -0,0 +1,199 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoHistory is returned by ScanHistory when there is no history to scan:
+// git is not installed or the directory is not in a git repository
+var ErrNoHistory = errors.New("no git history to scan")
+
+// HistoryOptions selects the commits ScanHistory scans
+type HistoryOptions struct {
+	// Revisions are passed to git rev-list; empty means --all
+	Revisions []string
+}
+
+// git helpers
+func gitAvailable() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// gitCommand runs git in repo, or in the current directory when repo is empty
+func gitCommand(ctx context.Context, repo string, args ...string) *exec.Cmd {
+	if repo != "" {
+		args = append([]string{"-C", repo}, args...)
+	}
+	return exec.CommandContext(ctx, "git", args...)
+}
+
+// gitRepository checks that repo is inside a git repository, returning
+// ErrNoHistory when git is missing or it is not
+func gitRepository(ctx context.Context, repo string) error {
+	if !gitAvailable() {
+		return fmt.Errorf("%w: git not available in PATH", ErrNoHistory)
+	}
+	out, err := gitCommand(ctx, repo, "rev-parse", "--git-dir").CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(out), "not a git repository") {
+			return fmt.Errorf("%w: not a git repository", ErrNoHistory)
+		}
+		return fmt.Errorf("git rev-parse failed: %w (%s)", err, out)
+	}
+	return nil
+}
+
+func gitAllCommits(ctx context.Context, repo string, revisions []string) ([]string, error) {
+	if len(revisions) == 0 {
+		revisions = []string{"--all"}
+	}
+	cmd := gitCommand(ctx, repo, append([]string{"rev-list"}, revisions...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("git rev-list failed: %w (%s)", err, out)
+	}
+	lines := strings.Fields(string(out))
+	return lines, nil
+}
+
+func gitShowCommitDiff(ctx context.Context, repo, commit string) (string, error) {
+	cmd := gitCommand(ctx, repo, "show", "--pretty=", "--unified=0", commit)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git show %s failed: %w", commit, err)
+	}
+	return string(out), nil
+}
+
+// scanGitHistory scans every commit diff. If onFindings is non-nil it is called
+// with each commit's findings as soon as that commit has been scanned. When ctx
+// is cancelled the findings of the commits scanned so far are returned with
+// ctx's error.
+func scanGitHistory(ctx context.Context, repo string, opts HistoryOptions, rules map[string]*Rule, config *Config, stats *Stats, onFindings func([]Finding)) ([]Finding, error) {
+	if err := gitRepository(ctx, repo); err != nil {
+		return nil, err
+	}
+	commits, err := gitAllCommits(ctx, repo, opts.Revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPipeline(SourceHistory, rules, config)
+	var results []Finding
+	for _, c := range commits {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		diff, err := gitShowCommitDiff(ctx, repo, c)
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			// skip commits that fail, but record them
+			stats.AddError(ScanError{Commit: c, Op: OpGitShow, Message: err.Error()})
+			continue
+		}
+
+		stats.incrementCommits()
+		diffChunks(c, diff, config, stats, func(chunk Chunk) {
+			p.scan(chunk)
+		})
+		found := p.finish()
+		results = append(results, found...)
+
+		if onFindings != nil && len(found) > 0 {
+			onFindings(found)
+		}
+	}
+	return results, nil
+}
+
+// hunkHeaderPattern matches "@@ -old[,n] +new[,n] @@" in a unified diff
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// diffChunks calls fn with a chunk for each added or removed line of a commit
+// diff. The chunk's Line is the line in the new file for added lines and in
+// the old file for removed lines; the file path and the kind of change are
+// recorded in Metadata. Files that shouldSkipFile rejects are skipped.
+func diffChunks(commit, diff string, config *Config, stats *Stats, fn func(Chunk)) {
+	s := bufio.NewScanner(strings.NewReader(diff))
+	s.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	currentFile := ""
+	skipCurrentFile := false
+	inHunk := false
+	oldLine, newLine := 0, 0
+
+	for s.Scan() {
+		line := s.Text()
+
+		// Track which file we're currently processing in the diff
+		// Git diff format: "diff --git a/path/to/file b/path/to/file"
+		if strings.HasPrefix(line, "diff --git") {
+			// Extract filename from diff header
+			parts := strings.Fields(line)
+			if len(parts) >= 4 {
+				// The filename is in parts[2] (a/filename) or parts[3] (b/filename)
+				// We'll use parts[3] (b/filename) as it represents the new version
+				currentFile = strings.TrimPrefix(parts[3], "b/")
+				skipCurrentFile = shouldSkipFile(currentFile)
+
+				if skipCurrentFile {
+					stats.incrementSkipped(SkipReasonHistory, false)
+					config.logf("Skipping file in git history: %s (commit: %s)", currentFile, commit[:8])
+				}
+			}
+			inHunk = false
+			continue
+		}
+
+		// Skip content if we're in a file that should be skipped
+		if skipCurrentFile {
+			continue
+		}
+
+		if m := hunkHeaderPattern.FindStringSubmatch(line); m != nil {
+			oldLine, _ = strconv.Atoi(m[1])
+			newLine, _ = strconv.Atoi(m[2])
+			inHunk = true
+			continue
+		}
+		if !inHunk {
+			// file header lines such as "--- a/path" and "+++ b/path"
+			continue
+		}
+
+		// Only scan added or removed lines
+		var lineNo int
+		var change string
+		switch {
+		case strings.HasPrefix(line, "+"):
+			lineNo, change = newLine, "added"
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			lineNo, change = oldLine, "removed"
+			oldLine++
+		default:
+			if strings.HasPrefix(line, " ") {
+				oldLine++
+				newLine++
+			}
+			continue
+		}
+
+		fn(Chunk{
+			Path:     "(git-history)",
+			Line:     lineNo,
+			Commit:   commit,
+			Text:     line[1:],
+			Metadata: map[string]string{"path": currentFile, "change": change},
+		})
+	}
+}
@@ -0,0 +1,441 @@
+package scanner
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxImageFileSize is the largest file read from an image layer
+const maxImageFileSize = 10 << 20
+
+// Whiteout markers in image layers (OCI image spec, "Representing Changes")
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// imageSource reads the files of a docker save tarball or an OCI image
+// layout, either as a directory or as a tarball
+type imageSource interface {
+	Open(name string) (io.ReadCloser, error)
+	Close() error
+}
+
+// dirImageSource reads an OCI layout directory
+type dirImageSource struct {
+	root string
+}
+
+func (d dirImageSource) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(d.root, filepath.FromSlash(name)))
+}
+
+func (d dirImageSource) Close() error { return nil }
+
+// tarImageSource reads an uncompressed image tarball, indexing entry offsets
+// so blobs can be opened in any order without extracting them
+type tarImageSource struct {
+	f       *os.File
+	entries map[string][2]int64 // name -> offset, size
+}
+
+// countingReader tracks how many bytes have been read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func openTarImageSource(name string) (*tarImageSource, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	src := &tarImageSource{f: f, entries: make(map[string][2]int64)}
+
+	// tar.Reader consumes whole 512-byte blocks, so the count after Next is
+	// the offset of the entry's data
+	cr := &countingReader{r: f}
+	tr := tar.NewReader(cr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("not an image tarball: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			src.entries[path.Clean(strings.TrimPrefix(hdr.Name, "./"))] = [2]int64{cr.n, hdr.Size}
+		}
+	}
+	return src, nil
+}
+
+func (t *tarImageSource) Open(name string) (io.ReadCloser, error) {
+	e, ok := t.entries[path.Clean(name)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return io.NopCloser(io.NewSectionReader(t.f, e[0], e[1])), nil
+}
+
+func (t *tarImageSource) Close() error { return t.f.Close() }
+
+// imageLayer is one layer of an image manifest
+type imageLayer struct {
+	Path   string // location of the layer blob in the source
+	Digest string
+}
+
+// imageManifest is an image's config blob and ordered layers, normalized
+// from either docker save's manifest.json or an OCI manifest
+type imageManifest struct {
+	Config string
+	Layers []imageLayer
+}
+
+// imageConfig is the subset of the image configuration secscan inspects
+type imageConfig struct {
+	Config struct {
+		Env []string `json:"Env"`
+	} `json:"config"`
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+	History []struct {
+		CreatedBy  string `json:"created_by"`
+		EmptyLayer bool   `json:"empty_layer"`
+	} `json:"history"`
+}
+
+// ociDescriptor is an OCI content descriptor
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+// blobPath returns the location of a blob in an OCI layout
+func blobPath(digest string) string {
+	algo, hex, ok := strings.Cut(digest, ":")
+	if !ok {
+		return digest
+	}
+	return "blobs/" + algo + "/" + hex
+}
+
+// readImageJSON decodes a JSON file from an image source
+func readImageJSON(src imageSource, name string, v interface{}) error {
+	rc, err := src.Open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+// imageManifests lists the images in a source. docker save's manifest.json
+// is preferred; otherwise the OCI index is followed, including nested indexes
+// of multi-platform images.
+func imageManifests(src imageSource) ([]imageManifest, error) {
+	var docker []struct {
+		Config string   `json:"Config"`
+		Layers []string `json:"Layers"`
+	}
+	err := readImageJSON(src, "manifest.json", &docker)
+	if err == nil {
+		var out []imageManifest
+		for _, m := range docker {
+			im := imageManifest{Config: m.Config}
+			for _, l := range m.Layers {
+				im.Layers = append(im.Layers, imageLayer{Path: l, Digest: l})
+			}
+			out = append(out, im)
+		}
+		return out, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("manifest.json: %w", err)
+	}
+
+	var index struct {
+		Manifests []ociDescriptor `json:"manifests"`
+	}
+	if err := readImageJSON(src, "index.json", &index); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errors.New("neither manifest.json (docker save) nor index.json (OCI layout) found")
+		}
+		return nil, fmt.Errorf("index.json: %w", err)
+	}
+
+	var out []imageManifest
+	var follow func(descs []ociDescriptor, depth int) error
+	follow = func(descs []ociDescriptor, depth int) error {
+		for _, d := range descs {
+			var m struct {
+				MediaType string          `json:"mediaType"`
+				Manifests []ociDescriptor `json:"manifests"`
+				Config    ociDescriptor   `json:"config"`
+				Layers    []ociDescriptor `json:"layers"`
+			}
+			if err := readImageJSON(src, blobPath(d.Digest), &m); err != nil {
+				return fmt.Errorf("manifest %s: %w", d.Digest, err)
+			}
+			if len(m.Manifests) > 0 {
+				if depth > 2 {
+					return fmt.Errorf("index %s is nested too deeply", d.Digest)
+				}
+				if err := follow(m.Manifests, depth+1); err != nil {
+					return err
+				}
+				continue
+			}
+			im := imageManifest{Config: blobPath(m.Config.Digest)}
+			for _, l := range m.Layers {
+				im.Layers = append(im.Layers, imageLayer{Path: blobPath(l.Digest), Digest: l.Digest})
+			}
+			out = append(out, im)
+		}
+		return nil
+	}
+	if err := follow(index.Manifests, 0); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// layerChanges records what a layer removed or replaced from the layers below it
+type layerChanges struct {
+	digest  string
+	files   map[string]bool
+	removed []string // paths deleted by whiteouts
+	opaque  []string // directories whose lower contents were hidden
+}
+
+// hides reports whether this layer deletes or replaces name from lower layers
+func (c *layerChanges) hides(name string) (string, bool) {
+	if c.files[name] {
+		return "overwritten", true
+	}
+	for _, r := range c.removed {
+		if name == r || strings.HasPrefix(name, r+"/") {
+			return "deleted", true
+		}
+	}
+	for _, d := range c.opaque {
+		if d == "." || strings.HasPrefix(name, d+"/") {
+			return "deleted", true
+		}
+	}
+	return "", false
+}
+
+// scanImage scans a docker save tarball or OCI layout. Every layer is scanned
+// in order, so files deleted or replaced by later layers are still inspected;
+// their findings record where they stopped being visible. The image config's
+// Env and history commands are scanned too.
+func scanImage(ctx context.Context, name string, rules map[string]*Rule, config *Config, stats *Stats) ([]Finding, error) {
+	var src imageSource
+	if info, err := os.Stat(name); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		src = dirImageSource{root: name}
+	} else {
+		t, err := openTarImageSource(name)
+		if err != nil {
+			return nil, err
+		}
+		src = t
+	}
+	defer src.Close()
+
+	manifests, err := imageManifests(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	// Layers shared between the images of an index are scanned once
+	seen := make(map[string]*layerChanges)
+	for _, m := range manifests {
+		var cfg imageConfig
+		if err := readImageJSON(src, m.Config, &cfg); err != nil {
+			stats.AddError(ScanError{Path: name + archiveSeparator + m.Config, Op: OpImage, Message: err.Error()})
+		} else {
+			findings = append(findings, scanImageConfig(name, &cfg, rules, config)...)
+		}
+
+		var changes []*layerChanges
+		var layerFindings [][]Finding
+		for i, layer := range m.Layers {
+			if ctx.Err() != nil {
+				break
+			}
+			digest := layer.Digest
+			if i < len(cfg.RootFS.DiffIDs) && !strings.Contains(digest, ":") {
+				digest = cfg.RootFS.DiffIDs[i]
+			}
+			if c, ok := seen[digest]; ok {
+				changes = append(changes, c)
+				layerFindings = append(layerFindings, nil)
+				continue
+			}
+
+			c, f, err := scanImageLayer(ctx, src, name, layer.Path, rules, config, stats)
+			if err != nil && ctx.Err() == nil {
+				stats.AddError(ScanError{Path: name + archiveSeparator + layer.Path, Op: OpImage, Message: err.Error()})
+			}
+			c.digest = digest
+			seen[digest] = c
+			for j := range f {
+				f[j].Metadata = copyMetadata(f[j].Metadata)
+				f[j].Metadata["layer_digest"] = digest
+				f[j].Metadata["layer_index"] = strconv.Itoa(i)
+			}
+			changes = append(changes, c)
+			layerFindings = append(layerFindings, f)
+		}
+
+		// Mark findings whose file is not in the final filesystem. When the
+		// scan was interrupted the later layers are unknown, so files not yet
+		// seen deleted are left unmarked.
+		allLayers := len(changes) == len(m.Layers)
+		for i, f := range layerFindings {
+			for j := range f {
+				p := strings.TrimPrefix(f[j].File, name+archiveSeparator)
+				for _, later := range changes[i+1:] {
+					if how, ok := later.hides(p); ok {
+						f[j].Metadata["final_image"] = "false"
+						f[j].Metadata[how+"_in_layer"] = later.digest
+						break
+					}
+				}
+				if allLayers && f[j].Metadata["final_image"] == "" {
+					f[j].Metadata["final_image"] = "true"
+				}
+			}
+			findings = append(findings, f...)
+		}
+	}
+	return findings, ctx.Err()
+}
+
+// scanImageLayer scans the files of one layer blob, recording its changes
+func scanImageLayer(ctx context.Context, src imageSource, image, blob string, rules map[string]*Rule, config *Config, stats *Stats) (*layerChanges, []Finding, error) {
+	c := &layerChanges{files: make(map[string]bool)}
+	rc, err := src.Open(blob)
+	if err != nil {
+		return c, nil, err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+	var in io.Reader = br
+	magic, _ := br.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return c, nil, err
+		}
+		defer gz.Close()
+		in = gz
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return c, nil, errors.New("zstd-compressed layers are not supported")
+	}
+
+	s := &archiveScanner{
+		ctx:      ctx,
+		rules:    rules,
+		config:   config,
+		stats:    stats,
+		budget:   math.MaxInt64,
+		maxEntry: maxImageFileSize,
+	}
+	tr := tar.NewReader(in)
+	for {
+		if err := ctx.Err(); err != nil {
+			return c, s.findings, err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return c, s.findings, err
+		}
+
+		p := path.Clean("/" + hdr.Name)[1:]
+		dir, base := path.Split(p)
+		dir = path.Clean(dir)
+		switch {
+		case base == whiteoutOpaque:
+			c.opaque = append(c.opaque, dir)
+		case strings.HasPrefix(base, whiteoutPrefix):
+			c.removed = append(c.removed, path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+		case hdr.Typeflag == tar.TypeReg:
+			c.files[p] = true
+			s.entry(image, p, tr, hdr.Size, 0)
+		}
+	}
+	return c, s.findings, nil
+}
+
+// buildArgPattern finds KEY=value assignments in history commands, as left by
+// ENV and ARG instructions and inline shell variables
+var buildArgPattern = regexp.MustCompile(`(?:^|\s)([A-Za-z_][A-Za-z0-9_]*)=("[^"]*"|'[^']*'|[^\s"']+)`)
+
+// scanImageConfig scans the image's environment and build history. Env
+// entries are reported like a .env file; history commands are reported with
+// the history index as their line number, one line per entry.
+func scanImageConfig(image string, cfg *imageConfig, rules map[string]*Rule, config *Config) []Finding {
+	var findings []Finding
+
+	env := []byte(strings.Join(cfg.Config.Env, "\n"))
+	envName := image + archiveSeparator + "config/Env"
+	envFindings, _ := scanLines(SourceArchive, envName, bytes.NewReader(env), rules, config)
+	for _, f := range scanStructured(envName, formatEnv, env, envFindings, config) {
+		f.Metadata = copyMetadata(f.Metadata)
+		f.Metadata["source"] = "config.Env"
+		findings = append(findings, f)
+	}
+
+	var commands []string
+	var pairs []keyValue
+	for i, h := range cfg.History {
+		commands = append(commands, strings.ReplaceAll(h.CreatedBy, "\n", " "))
+		for _, m := range buildArgPattern.FindAllStringSubmatch(h.CreatedBy, -1) {
+			pairs = append(pairs, keyValue{Path: m[1], Value: unquoteConfigValue(m[2], ""), Line: i + 1})
+		}
+	}
+	historyName := image + archiveSeparator + "config/history"
+	historyFindings, _ := scanLines(SourceArchive, historyName, strings.NewReader(strings.Join(commands, "\n")), rules, config)
+	historyFindings = reportCandidates(historyName, formatEnv, keyValueCandidates(pairs, nil), historyFindings, config)
+	for _, f := range historyFindings {
+		f.Metadata = copyMetadata(f.Metadata)
+		f.Metadata["source"] = "history"
+		f.Metadata["history_index"] = strconv.Itoa(f.Line - 1)
+		findings = append(findings, f)
+	}
+	return findings
+}
@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jwtRule is the rule whose matches are decoded by classifyJWT
+const jwtRule = "jwt"
+
+// Confidence of a jwt match, by what its claims say about it
+const (
+	jwtUndecodedConfidence  = 0.3  // header or claims are not JSON
+	jwtPublicConfidence     = 0.4  // expired, unsigned or a public (anon) key
+	jwtPrivilegedConfidence = 0.95 // a service role key
+)
+
+// jwtPrivilegedRoles are role claims granting admin access, such as Supabase's
+// service_role key, which bypasses row level security
+var jwtPrivilegedRoles = map[string]bool{
+	"service_role": true,
+	"admin":        true,
+	"superuser":    true,
+}
+
+// jwtPublicRoles are role claims of keys meant to ship to clients, such as
+// Supabase's anon key
+var jwtPublicRoles = map[string]bool{
+	"anon":      true,
+	"anonymous": true,
+	"public":    true,
+}
+
+// jwtClassification is what a token's claims say about it; an empty
+// severity or zero confidence keeps the rule's
+type jwtClassification struct {
+	severity   string
+	confidence float64
+	metadata   map[string]string
+}
+
+// classifyJWT decodes a token's header and claims into c.Metadata and sets
+// c's confidence, and its severity unless [severity] overrides the rule, from
+// what they say: expired and public tokens are low, unsigned ones medium and
+// privileged roles critical
+func classifyJWT(rule *Rule, _ Chunk, c *Candidate) bool {
+	class := decodeJWT(c.Value, time.Now())
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string, len(class.metadata))
+	}
+	for k, v := range class.metadata {
+		c.Metadata[k] = v
+	}
+	if class.confidence > 0 {
+		c.Confidence = class.confidence
+	}
+	if class.severity != "" && !rule.severityOverridden() {
+		c.Severity = class.severity
+	}
+	return true
+}
+
+// decodeJWT classifies a compact JWT as of now
+func decodeJWT(token string, now time.Time) jwtClassification {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return jwtClassification{confidence: jwtUndecodedConfidence}
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	var claims map[string]interface{}
+	if decodeJWTPart(parts[0], &header) != nil || header.Alg == "" || decodeJWTPart(parts[1], &claims) != nil {
+		return jwtClassification{confidence: jwtUndecodedConfidence}
+	}
+
+	md := map[string]string{"alg": header.Alg}
+	for _, claim := range []string{"iss", "aud", "sub", "role"} {
+		if v := jwtClaimString(claims[claim]); v != "" {
+			md[claim] = v
+		}
+	}
+	if _, ok := md["role"]; !ok {
+		if v := jwtClaimString(claims["roles"]); v != "" {
+			md["role"] = v
+		}
+	}
+	if md["iss"] == "supabase" || strings.HasSuffix(md["iss"], ".supabase.co/auth/v1") {
+		md["provider"] = "supabase"
+	}
+
+	class := jwtClassification{metadata: md}
+	if exp, ok := claims["exp"].(float64); ok {
+		at := time.Unix(int64(exp), 0).UTC()
+		md["exp"] = at.Format(time.RFC3339)
+		if at.Before(now) {
+			md["expired"] = "true"
+		}
+	}
+
+	switch {
+	case strings.EqualFold(header.Alg, "none"):
+		// anyone can forge an unsigned token; it is only worth a look as a
+		// sign that a consumer may accept alg "none"
+		md["unsigned"] = "true"
+		class.severity = SeverityMedium
+		class.confidence = jwtPublicConfidence
+	case md["expired"] == "true":
+		class.severity = SeverityLow
+		class.confidence = jwtPublicConfidence
+	case jwtHasRole(md["role"], jwtPrivilegedRoles):
+		class.severity = SeverityCritical
+		class.confidence = jwtPrivilegedConfidence
+	case jwtHasRole(md["role"], jwtPublicRoles):
+		class.severity = SeverityLow
+		class.confidence = jwtPublicConfidence
+	}
+	return class
+}
+
+// decodeJWTPart decodes one base64url segment of a token as JSON
+func decodeJWTPart(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// jwtClaimString renders a string, number or list claim, lists comma
+// separated and sorted
+func jwtClaimString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprint(v)
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s := jwtClaimString(item); s != "" {
+				values = append(values, s)
+			}
+		}
+		sort.Strings(values)
+		return strings.Join(values, ",")
+	}
+	return ""
+}
+
+// jwtHasRole reports whether a comma-separated role claim holds one of roles
+func jwtHasRole(claim string, roles map[string]bool) bool {
+	for _, r := range strings.Split(claim, ",") {
+		if roles[strings.ToLower(r)] {
+			return true
+		}
+	}
+	return false
+}
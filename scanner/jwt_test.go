@@ -0,0 +1,106 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+// testJWT builds a token from JSON header and claims with a dummy signature
+func testJWT(header, claims, signature string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(header)) + "." + enc([]byte(claims)) + "." + signature
+}
+
+// TestDecodeJWT verifies claim extraction and classification
+func TestDecodeJWT(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+	tests := []struct {
+		name       string
+		token      string
+		severity   string
+		confidence float64
+		metadata   map[string]string
+	}{
+		{
+			"supabase service role",
+			testJWT(hs256, `{"iss":"supabase","ref":"abcdefghijkl","role":"service_role","iat":1700000000,"exp":2000000000}`, "c2lnbmF0dXJl"),
+			SeverityCritical, jwtPrivilegedConfidence,
+			map[string]string{"alg": "HS256", "iss": "supabase", "role": "service_role", "provider": "supabase", "exp": "2033-05-18T03:33:20Z"},
+		},
+		{
+			"supabase anon",
+			testJWT(hs256, `{"iss":"supabase","ref":"abcdefghijkl","role":"anon","exp":2000000000}`, "c2lnbmF0dXJl"),
+			SeverityLow, jwtPublicConfidence,
+			map[string]string{"role": "anon", "provider": "supabase"},
+		},
+		{
+			"expired",
+			testJWT(`{"alg":"RS256","kid":"k1"}`, `{"iss":"https://auth.example.com","aud":["web","api"],"sub":"user-42","exp":1600000000}`, "c2lnbmF0dXJl"),
+			SeverityLow, jwtPublicConfidence,
+			map[string]string{"alg": "RS256", "iss": "https://auth.example.com", "aud": "api,web", "sub": "user-42", "exp": "2020-09-13T12:26:40Z", "expired": "true"},
+		},
+		{
+			"unsigned",
+			testJWT(`{"alg":"none"}`, `{"sub":"admin","role":"admin"}`, ""),
+			SeverityMedium, jwtPublicConfidence,
+			map[string]string{"alg": "none", "unsigned": "true", "role": "admin"},
+		},
+		{
+			"plain token keeps rule defaults",
+			testJWT(hs256, `{"sub":"svc","roles":["reader","writer"]}`, "c2lnbmF0dXJl"),
+			"", 0,
+			map[string]string{"sub": "svc", "role": "reader,writer"},
+		},
+		{
+			"not json",
+			"eyJub3QganNvbg.eyJhYmNkZWZnaGlqaw.c2ln",
+			"", jwtUndecodedConfidence,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decodeJWT(tt.token, now)
+			if got.severity != tt.severity || got.confidence != tt.confidence {
+				t.Errorf("severity %q confidence %.2f, want %q %.2f", got.severity, got.confidence, tt.severity, tt.confidence)
+			}
+			for k, v := range tt.metadata {
+				if got.metadata[k] != v {
+					t.Errorf("metadata[%s] = %q, want %q (all: %v)", k, got.metadata[k], v, got.metadata)
+				}
+			}
+		})
+	}
+}
+
+// TestJWTRule verifies that the jwt rule classifies its matches and that a
+// [severity] override for it wins over the claims
+func TestJWTRule(t *testing.T) {
+	token := testJWT(`{"alg":"HS256","typ":"JWT"}`, `{"iss":"supabase","ref":"abcdefghijkl","role":"service_role","exp":4000000000}`, "c2lnbmF0dXJlLXNpZ25hdHVyZQ")
+	line := `const key = "` + token + `"`
+
+	for _, tt := range []struct {
+		override string
+		want     string
+	}{{"", SeverityCritical}, {SeverityMedium, SeverityMedium}} {
+		rules, err := CompileRules(map[string]string{jwtRule: defaultRegexps[jwtRule]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.override != "" {
+			if err := applySeverities(rules, map[string]string{jwtRule: tt.override}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		found := newPipeline(SourceFile, rules, &Config{}).scan(Chunk{Path: "client.ts", Line: 3, Text: line})
+		if len(found) != 1 {
+			t.Fatalf("got %d findings", len(found))
+		}
+		f := found[0]
+		if f.Severity != tt.want || f.Confidence != jwtPrivilegedConfidence || f.Metadata["role"] != "service_role" {
+			t.Errorf("override %q: severity %s confidence %.2f metadata %v", tt.override, f.Severity, f.Confidence, f.Metadata)
+		}
+	}
+}
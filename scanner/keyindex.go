@@ -0,0 +1,272 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Confidence of a private_key match once its block has been parsed
+const (
+	keyParsedConfidence   = 0.99
+	keyUnparsedConfidence = 0.3 // truncated or placeholder key material
+)
+
+// maxKeyBlockLines bounds how many lines are gathered for one key block; a
+// 4096-bit RSA key is about 50 lines of PEM
+const maxKeyBlockLines = 256
+
+// pemBeginPattern matches the BEGIN line of the PEM blocks that are parsed
+var pemBeginPattern = regexp.MustCompile(`-----BEGIN ((?:[A-Z]+ )?PRIVATE KEY|(?:RSA )?PUBLIC KEY|CERTIFICATE)-----`)
+
+// sshPublicKeyPattern matches a public key in authorized_keys format, as in
+// .pub files
+var sshPublicKeyPattern = regexp.MustCompile(`(?:ssh-(?:rsa|dss|ed25519)|ecdsa-sha2-nistp(?:256|384|521)) (AAAA[0-9A-Za-z+/]+={0,3})`)
+
+// keyLocation is where a public key or certificate was found
+type keyLocation struct {
+	path string
+	info keyInfo
+}
+
+// keyIndex records the public keys and certificates seen while scanning, by
+// fingerprint, so private keys can be linked to them
+type keyIndex struct {
+	publicKeys   map[string][]keyLocation
+	certificates map[string][]keyLocation
+}
+
+func newKeyIndex() *keyIndex {
+	return &keyIndex{
+		publicKeys:   make(map[string][]keyLocation),
+		certificates: make(map[string][]keyLocation),
+	}
+}
+
+func (x *keyIndex) add(path string, info keyInfo) {
+	if x == nil || info.fingerprint == "" || info.private {
+		return
+	}
+	loc := keyLocation{path: path, info: info}
+	if info.certificate {
+		x.certificates[info.fingerprint] = append(x.certificates[info.fingerprint], loc)
+	} else {
+		x.publicKeys[info.fingerprint] = append(x.publicKeys[info.fingerprint], loc)
+	}
+}
+
+// link returns the metadata tying a private key fingerprint to the first
+// public key and certificate seen for it, by path
+func (x *keyIndex) link(fingerprint string) map[string]string {
+	md := make(map[string]string)
+	if loc, ok := firstKeyLocation(x.publicKeys[fingerprint]); ok {
+		md["public_key_path"] = loc.path
+	}
+	if loc, ok := firstKeyLocation(x.certificates[fingerprint]); ok {
+		md["certificate_path"] = loc.path
+		md["certificate_subject"] = loc.info.subject
+		if len(loc.info.dnsNames) > 0 {
+			md["certificate_dns_names"] = strings.Join(loc.info.dnsNames, ",")
+		}
+		md["certificate_not_after"] = loc.info.notAfter.UTC().Format(time.RFC3339)
+	}
+	return md
+}
+
+func firstKeyLocation(locs []keyLocation) (keyLocation, bool) {
+	if len(locs) == 0 {
+		return keyLocation{}, false
+	}
+	sorted := append([]keyLocation(nil), locs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].path < sorted[j].path })
+	return sorted[0], true
+}
+
+// keyBlocks gathers the lines of PEM and PuTTY key blocks from consecutive
+// chunks. When a block ends, a private key is parsed into the metadata of
+// its private_key finding; public keys and certificates are indexed.
+type keyBlocks struct {
+	rules  map[string]*Rule
+	config *Config
+	start  Chunk // chunk holding the BEGIN line
+	last   Chunk
+	lines  []string
+	putty  bool
+}
+
+func newKeyBlocks(rules map[string]*Rule, config *Config) *keyBlocks {
+	return &keyBlocks{rules: rules, config: config}
+}
+
+// scan feeds one chunk. findings holds the findings so far of the same input;
+// the private_key finding of a block that ends in chunk is updated in place.
+func (b *keyBlocks) scan(chunk Chunk, findings []Finding) {
+	text := strings.TrimSpace(chunk.Text)
+	if b.lines != nil {
+		if followsChunk(b.last, chunk) && len(b.lines) < maxKeyBlockLines {
+			b.last = chunk
+			b.add(text, findings)
+			return
+		}
+		// the block was cut short
+		b.lines = nil
+	}
+
+	if m := sshPublicKeyPattern.FindStringSubmatch(text); m != nil {
+		if blob, err := base64.StdEncoding.DecodeString(m[1]); err == nil {
+			if info, err := parseSSHPublicKey(blob); err == nil {
+				b.config.keys.add(keyChunkPath(chunk), info)
+			}
+		}
+	}
+
+	if strings.HasPrefix(text, "PuTTY-User-Key-File-") {
+		b.begin(chunk, text, true)
+		return
+	}
+	loc := pemBeginPattern.FindStringIndex(text)
+	if loc == nil {
+		return
+	}
+	if rest := text[loc[0]:]; strings.Contains(rest, "-----END ") {
+		// a whole block on one line is an escaped string, such as a JSON value
+		b.begin(chunk, cutAfterPEMEnd(strings.ReplaceAll(rest, `\n`, "\n")), false)
+		b.parse(findings)
+		return
+	}
+	b.begin(chunk, text[loc[0]:loc[1]], false)
+}
+
+func (b *keyBlocks) begin(chunk Chunk, text string, putty bool) {
+	b.start, b.last, b.putty = chunk, chunk, putty
+	b.lines = []string{text}
+}
+
+// add appends a line to the current block, finishing it on its last line
+func (b *keyBlocks) add(text string, findings []Finding) {
+	switch {
+	case b.putty:
+		b.lines = append(b.lines, text)
+		if strings.HasPrefix(text, "Private-MAC:") {
+			b.parse(findings)
+		}
+	case strings.Contains(text, "-----END "):
+		b.lines = append(b.lines, cutAfterPEMEnd(text[strings.Index(text, "-----END "):]))
+		b.parse(findings)
+	default:
+		// quotes and commas of keys written as string lists
+		b.lines = append(b.lines, strings.Trim(text, `"',`))
+	}
+}
+
+// parse parses the current block
+func (b *keyBlocks) parse(findings []Finding) {
+	private := b.putty || strings.Contains(b.lines[0], "PRIVATE KEY")
+	info, err := parseKeyBlock(strings.Join(b.lines, "\n"))
+	b.lines = nil
+	if !private {
+		if err == nil {
+			b.config.keys.add(keyChunkPath(b.start), info)
+		}
+		return
+	}
+
+	for i := len(findings) - 1; i >= 0; i-- {
+		f := &findings[i]
+		if f.Pattern == privateKeyRule && f.File == b.start.Path && f.Commit == b.start.Commit && f.Line == b.start.Line {
+			b.describe(f, info, err)
+			return
+		}
+	}
+}
+
+// describe records a parsed private key in its finding. Keys that fail to
+// parse are likely truncated or placeholders and are demoted; encrypted keys
+// are high rather than critical unless [severity] overrides the rule.
+func (b *keyBlocks) describe(f *Finding, info keyInfo, err error) {
+	if f.Metadata == nil {
+		f.Metadata = make(map[string]string)
+	}
+	if err != nil {
+		f.Metadata["key_parsed"] = "false"
+		f.Confidence = keyUnparsedConfidence
+		return
+	}
+	for k, v := range info.metadata() {
+		f.Metadata[k] = v
+	}
+	f.Metadata["key_parsed"] = "true"
+	f.Confidence = keyParsedConfidence
+	if rule := b.rules[privateKeyRule]; info.encrypted && rule != nil && !rule.severityOverridden() {
+		f.Severity = SeverityHigh
+	}
+	if info.fingerprint != "" {
+		// distinct keys in one file or commit would otherwise share the
+		// hash of their BEGIN line
+		f.Hash = generateHash(findingHashKey(b.start), f.Pattern, info.fingerprint)
+	}
+}
+
+// finish drops a block cut short by the end of the input
+func (b *keyBlocks) finish(findings []Finding) []Finding {
+	b.lines = nil
+	return findings
+}
+
+// followsChunk reports whether chunk is the line after last in the same input
+func followsChunk(last, chunk Chunk) bool {
+	return chunk.Path == last.Path && chunk.Commit == last.Commit && chunk.Line == last.Line+1 &&
+		chunk.Metadata["path"] == last.Metadata["path"] && chunk.Metadata["change"] == last.Metadata["change"]
+}
+
+// cutAfterPEMEnd drops what follows the "-----END ...-----" marker in s
+func cutAfterPEMEnd(s string) string {
+	i := strings.Index(s, "-----END ")
+	if i < 0 {
+		return s
+	}
+	end := strings.Index(s[i+len("-----END "):], "-----")
+	if end < 0 {
+		return s
+	}
+	return s[:i+len("-----END ")+end+len("-----")]
+}
+
+// keyChunkPath names where a key was found, with the commit for history
+func keyChunkPath(chunk Chunk) string {
+	if chunk.Commit != "" {
+		return chunk.Metadata["path"] + "@" + chunk.Commit
+	}
+	return chunk.Path
+}
+
+// linkKeys returns copies of findings with each parsed private key linked to
+// the public keys and certificates in index that share its fingerprint
+func linkKeys(findings []Finding, index *keyIndex) []Finding {
+	out := make([]Finding, len(findings))
+	copy(out, findings)
+	if index == nil {
+		return out
+	}
+	for i, f := range out {
+		fp := f.Metadata["key_fingerprint"]
+		if f.Pattern != privateKeyRule || fp == "" {
+			continue
+		}
+		link := index.link(fp)
+		if len(link) == 0 {
+			continue
+		}
+		md := make(map[string]string, len(f.Metadata)+len(link))
+		for k, v := range f.Metadata {
+			md[k] = v
+		}
+		for k, v := range link {
+			md[k] = v
+		}
+		out[i].Metadata = md
+	}
+	return out
+}
@@ -0,0 +1,380 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// privateKeyRule is the rule whose matches are parsed as key blocks
+const privateKeyRule = "private_key"
+
+// Key formats reported in key_format metadata
+const (
+	keyFormatPKCS1   = "pkcs1"
+	keyFormatPKCS8   = "pkcs8"
+	keyFormatSEC1    = "sec1"
+	keyFormatDSA     = "dsa"
+	keyFormatOpenSSH = "openssh"
+	keyFormatPuTTY   = "putty"
+)
+
+// keyInfo describes a parsed private key, public key or certificate
+type keyInfo struct {
+	private   bool
+	format    string
+	algorithm string // rsa, ecdsa, ed25519 or dsa; "" when encrypted away
+	bits      int
+	encrypted bool
+	comment   string
+	// fingerprint is the OpenSSH SHA256 fingerprint of the public key, the
+	// same for every format holding it; "" when the key is encrypted away
+	fingerprint string
+
+	// set for certificates
+	certificate bool
+	subject     string
+	dnsNames    []string
+	notAfter    time.Time
+}
+
+// metadata returns the finding metadata describing a private key
+func (k keyInfo) metadata() map[string]string {
+	md := map[string]string{
+		"key_format":    k.format,
+		"key_encrypted": strconv.FormatBool(k.encrypted),
+	}
+	if k.algorithm != "" {
+		md["key_algorithm"] = k.algorithm
+	}
+	if k.bits > 0 {
+		md["key_bits"] = strconv.Itoa(k.bits)
+	}
+	if k.fingerprint != "" {
+		md["key_fingerprint"] = k.fingerprint
+	}
+	if k.comment != "" {
+		md["key_comment"] = k.comment
+	}
+	return md
+}
+
+// parseKeyBlock parses a PEM block or a PuTTY key file
+func parseKeyBlock(text string) (keyInfo, error) {
+	if strings.HasPrefix(text, "PuTTY-User-Key-File-") {
+		return parsePuTTYKey(text)
+	}
+	block, _ := pem.Decode([]byte(text))
+	if block == nil {
+		return keyInfo{}, errors.New("not a PEM block")
+	}
+
+	// Legacy PEM encryption leaves the key type readable but not the key
+	legacyEncrypted := strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED")
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		k := keyInfo{private: true, format: keyFormatPKCS1, algorithm: "rsa", encrypted: legacyEncrypted}
+		if k.encrypted {
+			return k, nil
+		}
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return k, err
+		}
+		return k, k.setPublicKey(&key.PublicKey)
+	case "EC PRIVATE KEY":
+		k := keyInfo{private: true, format: keyFormatSEC1, algorithm: "ecdsa", encrypted: legacyEncrypted}
+		if k.encrypted {
+			return k, nil
+		}
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return k, err
+		}
+		return k, k.setPublicKey(&key.PublicKey)
+	case "DSA PRIVATE KEY":
+		k := keyInfo{private: true, format: keyFormatDSA, algorithm: "dsa", encrypted: legacyEncrypted}
+		if k.encrypted {
+			return k, nil
+		}
+		var key struct {
+			Version       int
+			P, Q, G, Y, X *big.Int
+		}
+		if _, err := asn1.Unmarshal(block.Bytes, &key); err != nil {
+			return k, err
+		}
+		return k, k.setPublicKey(&dsa.PublicKey{Parameters: dsa.Parameters{P: key.P, Q: key.Q, G: key.G}, Y: key.Y})
+	case "PRIVATE KEY":
+		k := keyInfo{private: true, format: keyFormatPKCS8}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return k, err
+		}
+		signer, ok := key.(interface{ Public() crypto.PublicKey })
+		if !ok {
+			return k, errors.New("unsupported PKCS#8 key")
+		}
+		return k, k.setPublicKey(signer.Public())
+	case "ENCRYPTED PRIVATE KEY":
+		return keyInfo{private: true, format: keyFormatPKCS8, encrypted: true}, nil
+	case "OPENSSH PRIVATE KEY":
+		return parseOpenSSHKey(block.Bytes)
+	case "PUBLIC KEY":
+		var k keyInfo
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return k, err
+		}
+		return k, k.setPublicKey(pub)
+	case "RSA PUBLIC KEY":
+		var k keyInfo
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return k, err
+		}
+		return k, k.setPublicKey(pub)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return keyInfo{}, err
+		}
+		k := keyInfo{
+			certificate: true,
+			subject:     cert.Subject.CommonName,
+			dnsNames:    cert.DNSNames,
+			notAfter:    cert.NotAfter,
+		}
+		if k.subject == "" {
+			k.subject = cert.Subject.String()
+		}
+		return k, k.setPublicKey(cert.PublicKey)
+	}
+	return keyInfo{}, errors.New("unsupported PEM type " + block.Type)
+}
+
+// setPublicKey records the algorithm, size and fingerprint of a public key
+func (k *keyInfo) setPublicKey(pub crypto.PublicKey) error {
+	var blob []byte
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		k.algorithm, k.bits = "rsa", pub.N.BitLen()
+		blob = sshWire("ssh-rsa", big.NewInt(int64(pub.E)), pub.N)
+	case *ecdsa.PublicKey:
+		size := pub.Curve.Params().BitSize
+		curve := "nistp" + strconv.Itoa(size)
+		k.algorithm, k.bits = "ecdsa", size
+		blob = sshWire("ecdsa-sha2-"+curve, curve, ecPoint(pub))
+	case ed25519.PublicKey:
+		k.algorithm, k.bits = "ed25519", 256
+		blob = sshWire("ssh-ed25519", []byte(pub))
+	case *dsa.PublicKey:
+		k.algorithm, k.bits = "dsa", pub.P.BitLen()
+		blob = sshWire("ssh-dss", pub.P, pub.Q, pub.G, pub.Y)
+	default:
+		return errors.New("unsupported public key type")
+	}
+	k.fingerprint = sshFingerprint(blob)
+	return nil
+}
+
+// ecPoint encodes an ECDSA public key as an uncompressed point, as
+// elliptic.Marshal did. PublicKey.ECDH needs Go 1.20, newer than go.mod.
+func ecPoint(pub *ecdsa.PublicKey) []byte {
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	point := make([]byte, 1+2*size)
+	point[0] = 4
+	pub.X.FillBytes(point[1 : 1+size])
+	pub.Y.FillBytes(point[1+size:])
+	return point
+}
+
+// sshWire encodes strings, byte strings and integers in the SSH wire format
+// used for public key blobs
+func sshWire(fields ...interface{}) []byte {
+	var b bytes.Buffer
+	writeString := func(p []byte) {
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(len(p)))
+		b.Write(n[:])
+		b.Write(p)
+	}
+	for _, f := range fields {
+		switch f := f.(type) {
+		case string:
+			writeString([]byte(f))
+		case []byte:
+			writeString(f)
+		case *big.Int:
+			// mpint: big-endian with a leading zero byte when the top bit is set
+			p := f.Bytes()
+			if len(p) > 0 && p[0]&0x80 != 0 {
+				p = append([]byte{0}, p...)
+			}
+			writeString(p)
+		}
+	}
+	return b.Bytes()
+}
+
+// sshFingerprint returns the OpenSSH SHA256 fingerprint of a public key blob
+func sshFingerprint(blob []byte) string {
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// sshReader reads the fields of SSH wire format data
+type sshReader struct {
+	p   []byte
+	err error
+}
+
+func (r *sshReader) uint32() uint32 {
+	if r.err != nil || len(r.p) < 4 {
+		r.err = errors.New("truncated key data")
+		return 0
+	}
+	n := binary.BigEndian.Uint32(r.p)
+	r.p = r.p[4:]
+	return n
+}
+
+func (r *sshReader) bytes() []byte {
+	n := r.uint32()
+	if r.err != nil || uint64(n) > uint64(len(r.p)) {
+		r.err = errors.New("truncated key data")
+		return nil
+	}
+	b := r.p[:n]
+	r.p = r.p[n:]
+	return b
+}
+
+// parseSSHPublicKey reads the algorithm and size of an SSH public key blob
+func parseSSHPublicKey(blob []byte) (keyInfo, error) {
+	r := &sshReader{p: blob}
+	keyType := string(r.bytes())
+	k := keyInfo{fingerprint: sshFingerprint(blob)}
+	switch {
+	case keyType == "ssh-rsa":
+		r.bytes() // e
+		k.algorithm, k.bits = "rsa", new(big.Int).SetBytes(r.bytes()).BitLen()
+	case keyType == "ssh-dss":
+		k.algorithm, k.bits = "dsa", new(big.Int).SetBytes(r.bytes()).BitLen()
+	case keyType == "ssh-ed25519":
+		k.algorithm, k.bits = "ed25519", 256
+	case strings.HasPrefix(keyType, "ecdsa-sha2-nistp"):
+		k.algorithm = "ecdsa"
+		k.bits, _ = strconv.Atoi(strings.TrimPrefix(keyType, "ecdsa-sha2-nistp"))
+	default:
+		k.algorithm = keyType
+	}
+	return k, r.err
+}
+
+// openSSHKeyMagic starts the body of an OPENSSH PRIVATE KEY block
+const openSSHKeyMagic = "openssh-key-v1\x00"
+
+// openSSHPrivateFields is the number of key fields before the comment in
+// the private section, by key type
+var openSSHPrivateFields = map[string]int{
+	"ssh-rsa":             6, // n, e, d, iqmp, p, q
+	"ssh-dss":             5, // p, q, g, y, x
+	"ssh-ed25519":         2, // public key, private key
+	"ecdsa-sha2-nistp256": 3, // curve, public point, private scalar
+	"ecdsa-sha2-nistp384": 3,
+	"ecdsa-sha2-nistp521": 3,
+}
+
+// parseOpenSSHKey parses the openssh-key-v1 format. The public key is stored
+// in the clear, so encrypted keys still have a fingerprint; the comment is
+// only readable in unencrypted keys.
+func parseOpenSSHKey(data []byte) (keyInfo, error) {
+	if !bytes.HasPrefix(data, []byte(openSSHKeyMagic)) {
+		return keyInfo{private: true, format: keyFormatOpenSSH}, errors.New("bad openssh-key-v1 magic")
+	}
+	r := &sshReader{p: data[len(openSSHKeyMagic):]}
+	cipher := string(r.bytes())
+	r.bytes() // kdf name
+	r.bytes() // kdf options
+	if n := r.uint32(); r.err == nil && n != 1 {
+		return keyInfo{private: true, format: keyFormatOpenSSH}, errors.New("unsupported key count")
+	}
+	pub := r.bytes()
+	private := r.bytes()
+	if r.err != nil {
+		return keyInfo{private: true, format: keyFormatOpenSSH}, r.err
+	}
+
+	k, err := parseSSHPublicKey(pub)
+	k.private, k.format, k.encrypted = true, keyFormatOpenSSH, cipher != "none"
+	if err != nil || k.encrypted {
+		return k, err
+	}
+
+	pr := &sshReader{p: private}
+	if check1, check2 := pr.uint32(), pr.uint32(); check1 != check2 {
+		return k, errors.New("check bytes do not match")
+	}
+	keyType := string(pr.bytes())
+	for i := 0; i < openSSHPrivateFields[keyType]; i++ {
+		pr.bytes()
+	}
+	if comment := pr.bytes(); pr.err == nil {
+		k.comment = string(comment)
+	}
+	return k, nil
+}
+
+// parsePuTTYKey parses a PuTTY .ppk file. As with OpenSSH keys, the public key
+// and comment are stored in the clear.
+func parsePuTTYKey(text string) (keyInfo, error) {
+	k := keyInfo{private: true, format: keyFormatPuTTY}
+	var public strings.Builder
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		name, value, ok := strings.Cut(lines[i], ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch name {
+		case "Encryption":
+			k.encrypted = value != "none"
+		case "Comment":
+			k.comment = value
+		case "Public-Lines", "Private-Lines":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || i+1+n > len(lines) {
+				return k, errors.New("bad " + name)
+			}
+			if name == "Public-Lines" {
+				for _, l := range lines[i+1 : i+1+n] {
+					public.WriteString(strings.TrimSpace(l))
+				}
+			}
+			i += n
+		}
+	}
+	blob, err := base64.StdEncoding.DecodeString(public.String())
+	if err != nil {
+		return k, err
+	}
+	pub, err := parseSSHPublicKey(blob)
+	k.algorithm, k.bits, k.fingerprint = pub.algorithm, pub.bits, pub.fingerprint
+	return k, err
+}
@@ -0,0 +1,176 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// kubernetesSecretRuleName is the pattern name of values found in Secret manifests
+const kubernetesSecretRuleName = "kubernetes_secret"
+
+// kubernetesResource identifies the Kubernetes object a YAML document describes
+type kubernetesResource struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Document  int
+}
+
+// kubernetesResourceOf returns the resource described by a YAML document, if
+// the document has both apiVersion and kind
+func kubernetesResourceOf(doc *yamlNode, index int) (kubernetesResource, bool) {
+	kind := doc.Get("kind").String()
+	if kind == "" || doc.Get("apiVersion").String() == "" {
+		return kubernetesResource{}, false
+	}
+	meta := doc.Get("metadata")
+	return kubernetesResource{
+		Kind:      kind,
+		Name:      meta.Get("name").String(),
+		Namespace: meta.Get("namespace").String(),
+		Document:  index,
+	}, true
+}
+
+// metadata returns the resource fields recorded on findings
+func (r kubernetesResource) metadata() map[string]string {
+	m := map[string]string{
+		"kind":     r.Kind,
+		"document": strconv.Itoa(r.Document),
+	}
+	if r.Name != "" {
+		m["name"] = r.Name
+	}
+	if r.Namespace != "" {
+		m["namespace"] = r.Namespace
+	}
+	return m
+}
+
+// scanYAMLDocument proposes the secret candidates of one YAML document. For
+// Kubernetes manifests, line findings inside the document (end is its last
+// line, 0 for the final document) are annotated with the resource, Secret
+// data is decoded and env entries are inspected.
+func scanYAMLDocument(format string, doc *yamlNode, index, end int, findings []Finding) ([]structuredCandidate, []Finding) {
+	res, isKubernetes := kubernetesResourceOf(doc, index)
+	var metadata map[string]string
+	if isKubernetes {
+		metadata = res.metadata()
+		for i := range findings {
+			f := &findings[i]
+			if f.Line < doc.Line || end > 0 && f.Line > end {
+				continue
+			}
+			if f.Metadata == nil {
+				f.Metadata = make(map[string]string)
+			}
+			for k, v := range metadata {
+				f.Metadata[k] = v
+			}
+		}
+	}
+
+	var pairs []keyValue
+	flattenYAML(doc, "", &pairs)
+	var kept []keyValue
+	for _, kv := range pairs {
+		// Secret values are reported below whatever their key is called
+		if res.Kind == "Secret" && (strings.HasPrefix(kv.Path, "data.") || strings.HasPrefix(kv.Path, "stringData.")) {
+			continue
+		}
+		if format == formatHelmValues && isSecretReferenceKey(kv.Path) {
+			continue
+		}
+		kept = append(kept, kv)
+	}
+
+	candidates := keyValueCandidates(kept, metadata)
+	candidates = append(candidates, envVarCandidates(doc, "", metadata)...)
+	if res.Kind == "Secret" {
+		candidates = append(candidates, kubernetesSecretCandidates(doc, metadata)...)
+	}
+	return candidates, findings
+}
+
+// isSecretReferenceKey reports whether a Helm value names an existing Secret
+// (existingSecret, auth.existingSecretPasswordKey) rather than holding one
+func isSecretReferenceKey(path string) bool {
+	key := path
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		key = key[i+1:]
+	}
+	return strings.HasPrefix(strings.ToLower(key), "existing")
+}
+
+// envVarCandidates finds "- name: X, value: Y" entries anywhere in a document,
+// as used by container env lists and most Helm charts' extra env values
+func envVarCandidates(n *yamlNode, path string, metadata map[string]string) []structuredCandidate {
+	var out []structuredCandidate
+	switch n.Kind {
+	case yamlMapping:
+		name, value := n.Get("name"), n.Get("value")
+		if name != nil && value != nil && value.Kind == yamlScalar && isSecretKey(name.String()) {
+			m := map[string]string{"env_name": name.String()}
+			for k, v := range metadata {
+				m[k] = v
+			}
+			out = append(out, structuredCandidate{
+				keyValue: keyValue{Path: joinKeyPath(path, "value"), Value: value.Value, Line: value.Line},
+				Pattern:  structuredRuleName,
+				Metadata: m,
+			})
+		}
+		for _, p := range n.Pairs {
+			out = append(out, envVarCandidates(p.Value, joinKeyPath(path, p.Key), metadata)...)
+		}
+	case yamlSequence:
+		for i, item := range n.Items {
+			out = append(out, envVarCandidates(item, fmt.Sprintf("%s[%d]", path, i), metadata)...)
+		}
+	}
+	return out
+}
+
+// kubernetesSecretCandidates reports the base64-decoded data and the plain
+// stringData values of a Secret. Public certificates (*.crt) are skipped.
+func kubernetesSecretCandidates(doc *yamlNode, metadata map[string]string) []structuredCandidate {
+	var out []structuredCandidate
+	for _, section := range []string{"data", "stringData"} {
+		node := doc.Get(section)
+		if node == nil || node.Kind != yamlMapping {
+			continue
+		}
+		for _, p := range node.Pairs {
+			if p.Value.Kind != yamlScalar || strings.HasSuffix(p.Key, ".crt") || strings.HasSuffix(p.Key, ".cert") {
+				continue
+			}
+
+			value := p.Value.Value
+			m := make(map[string]string, len(metadata)+1)
+			for k, v := range metadata {
+				m[k] = v
+			}
+			if section == "data" {
+				decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+				if err != nil {
+					continue
+				}
+				if utf8.Valid(decoded) {
+					value = string(decoded)
+				}
+				m["encoding"] = "base64"
+			}
+
+			out = append(out, structuredCandidate{
+				keyValue:  keyValue{Path: section + "." + p.Key, Value: value, Line: p.Value.Line},
+				Pattern:   kubernetesSecretRuleName,
+				Metadata:  m,
+				Multiline: true,
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// notebookTextMimeTypes are the rich output types scanned in notebooks.
+// Images and other binary payloads are skipped.
+var notebookTextMimeTypes = []string{
+	"text/plain", "text/html", "text/markdown", "text/latex",
+	"application/json", "application/javascript",
+}
+
+// notebookText is a notebook string field, stored either as one string or as
+// a list of lines
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = notebookText(s)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(b, &lines); err != nil {
+		return err
+	}
+	*t = notebookText(strings.Join(lines, ""))
+	return nil
+}
+
+// notebook is the subset of the Jupyter nbformat 4 document secscan reads
+type notebook struct {
+	Cells []struct {
+		ID       string       `json:"id"`
+		CellType string       `json:"cell_type"`
+		Source   notebookText `json:"source"`
+		Outputs  []struct {
+			OutputType string                     `json:"output_type"`
+			Text       notebookText               `json:"text"`
+			Data       map[string]json.RawMessage `json:"data"`
+			EName      string                     `json:"ename"`
+			EValue     string                     `json:"evalue"`
+			Traceback  []string                   `json:"traceback"`
+		} `json:"outputs"`
+	} `json:"cells"`
+}
+
+// isNotebookFile reports whether a file is a Jupyter notebook
+func isNotebookFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".ipynb")
+}
+
+// scanNotebook scans the source and text outputs of each notebook cell on
+// their own. Findings report the line within the cell or output, with the
+// cell index recorded in Metadata. It returns false when the file is not a
+// notebook document, so the caller can fall back to plain line scanning.
+func scanNotebook(source, path string, data []byte, rules map[string]*Rule, config *Config) ([]Finding, bool) {
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil || nb.Cells == nil {
+		return nil, false
+	}
+
+	var findings []Finding
+	scan := func(text string, metadata map[string]string) {
+		found, _ := scanLines(source, path, strings.NewReader(text), rules, config)
+		for _, f := range found {
+			if f.Metadata == nil {
+				f.Metadata = make(map[string]string)
+			}
+			for k, v := range metadata {
+				f.Metadata[k] = v
+			}
+			f.Metadata["cell_line"] = strconv.Itoa(f.Line)
+			findings = append(findings, f)
+		}
+	}
+
+	for i, cell := range nb.Cells {
+		base := map[string]string{
+			"cell":      strconv.Itoa(i),
+			"cell_type": cell.CellType,
+		}
+		if cell.ID != "" {
+			base["cell_id"] = cell.ID
+		}
+
+		cellMeta := copyMetadata(base)
+		cellMeta["section"] = "source"
+		scan(string(cell.Source), cellMeta)
+
+		for j, out := range cell.Outputs {
+			m := copyMetadata(base)
+			m["section"] = "output"
+			m["output"] = strconv.Itoa(j)
+			m["output_type"] = out.OutputType
+
+			switch out.OutputType {
+			case "stream":
+				scan(string(out.Text), m)
+			case "error":
+				scan(out.EName+": "+out.EValue+"\n"+strings.Join(out.Traceback, "\n"), m)
+			default:
+				for _, mime := range notebookTextMimeTypes {
+					raw, ok := out.Data[mime]
+					if !ok {
+						continue
+					}
+					var text notebookText
+					if mime == "application/json" {
+						text = notebookText(raw)
+					} else if err := json.Unmarshal(raw, &text); err != nil {
+						continue
+					}
+					mm := copyMetadata(m)
+					mm["mime_type"] = mime
+					scan(string(text), mm)
+				}
+			}
+		}
+	}
+	return findings, true
+}
+
+// copyMetadata returns a shallow copy of a metadata map
+func copyMetadata(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m)+4)
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// FindingLocation formats where a finding is for terminal output. Notebook
+// findings are addressed by cell rather than by line of the JSON file.
+func FindingLocation(f Finding) string {
+	cell, ok := f.Metadata["cell"]
+	if !ok {
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	if out, ok := f.Metadata["output"]; ok {
+		return fmt.Sprintf("%s cell %s output %s line %d", f.File, cell, out, f.Line)
+	}
+	return fmt.Sprintf("%s cell %s line %d", f.File, cell, f.Line)
+}
@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"os"
+	"testing"
+)
+
+// TestScanNotebook verifies per-cell line numbers and skipping of image outputs
+func TestScanNotebook(t *testing.T) {
+	data, err := os.ReadFile("testdata/notebook.ipynb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := CompileRules(map[string]string{
+		"github_pat":  defaultRegexps["github_pat"],
+		"slack_token": defaultRegexps["slack_token"],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings, ok := scanNotebook(SourceFile, "analysis.ipynb", data, rules, &Config{EntropyThreshold: 4.5})
+	if !ok {
+		t.Fatal("notebook was not recognised")
+	}
+
+	byPattern := make(map[string]Finding)
+	for _, f := range findings {
+		if f.Pattern == "high_entropy" && f.Metadata["section"] == "output" {
+			t.Errorf("image output was scanned: %+v", f)
+		}
+		byPattern[f.Pattern] = f
+	}
+
+	pat, ok := byPattern["github_pat"]
+	if !ok {
+		t.Fatal("missing github_pat finding in cell source")
+	}
+	if pat.Line != 2 || pat.Metadata["cell"] != "1" || pat.Metadata["cell_id"] != "c1" || pat.Metadata["section"] != "source" {
+		t.Errorf("unexpected source finding: line %d %v", pat.Line, pat.Metadata)
+	}
+
+	slack, ok := byPattern["slack_token"]
+	if !ok {
+		t.Fatal("missing slack_token finding in cell output")
+	}
+	if slack.Line != 2 || slack.Metadata["cell"] != "2" || slack.Metadata["output"] != "0" || slack.Metadata["output_type"] != "stream" {
+		t.Errorf("unexpected output finding: line %d %v", slack.Line, slack.Metadata)
+	}
+
+	if got := FindingLocation(slack); got != "analysis.ipynb cell 2 output 0 line 2" {
+		t.Errorf("FindingLocation() = %q", got)
+	}
+}
+
+// TestScanNotebookFallback verifies that invalid notebooks are left to the line scanner
+func TestScanNotebookFallback(t *testing.T) {
+	if _, ok := scanNotebook(SourceFile, "broken.ipynb", []byte("not json"), nil, &Config{}); ok {
+		t.Error("expected invalid JSON to fall back")
+	}
+}
@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Kinds of source that yield chunks. Each has its own SourceTuning.
+const (
+	SourceFile    = "file"    // files on disk
+	SourceStdin   = "stdin"   // content passed to ScanReader, such as stdin
+	SourceArchive = "archive" // archive entries and container image layers
+	SourceHistory = "history" // added and removed lines of git commits
+)
+
+// SourceTuning adjusts detection for one kind of source
+type SourceTuning struct {
+	// SkipComments ignores lines starting with //, #, /* or *
+	SkipComments bool
+	// DecodeBlobs scans the decoded text of base64 and hex blobs
+	DecodeBlobs bool
+	// EntropyOffset is added to the entropy threshold
+	EntropyOffset float64
+	// EntropyConfidence is the confidence of high_entropy findings
+	EntropyConfidence float64
+	// RuleConfidence, when set, replaces the confidence of regex rule matches
+	RuleConfidence float64
+}
+
+// DefaultSourceTuning returns the tuning used for each source kind. History
+// is noisier than the working tree, so it needs higher entropy and gives rule
+// matches a fixed confidence.
+func DefaultSourceTuning() map[string]SourceTuning {
+	file := SourceTuning{SkipComments: true, DecodeBlobs: true, EntropyConfidence: 0.6}
+	return map[string]SourceTuning{
+		SourceFile:    file,
+		SourceStdin:   file,
+		SourceArchive: file,
+		SourceHistory: {EntropyOffset: 0.5, EntropyConfidence: 0.55, RuleConfidence: 0.85},
+	}
+}
+
+// sourceTuning returns the configured tuning for a source, falling back to
+// its default
+func sourceTuning(source string, config *Config) SourceTuning {
+	if t, ok := config.SourceTuning[source]; ok {
+		return t
+	}
+	defaults := DefaultSourceTuning()
+	if t, ok := defaults[source]; ok {
+		return t
+	}
+	return defaults[SourceFile]
+}
+
+// pipeline runs the detect and filter stages over the chunks of one source:
+// each chunk is checked by the detectors, its encoded blobs are decoded and
+// scanned, and the candidates that survive the allow patterns become findings.
+// The line stages then look across the chunks of each input.
+type pipeline struct {
+	rules     map[string]*Rule
+	config    *Config
+	tuning    SourceTuning
+	detectors []Detector
+	stages    []lineStage
+	findings  []Finding // of the current input
+}
+
+// lineStage follows the chunks of one input in order, for checks that span
+// lines. scan sees each chunk after the detectors, with the findings of the
+// input so far, and may update them in place; finish returns the findings
+// once the input ends.
+type lineStage interface {
+	scan(chunk Chunk, findings []Finding)
+	finish(findings []Finding) []Finding
+}
+
+// newPipeline prepares the detectors for a source
+func newPipeline(source string, rules map[string]*Rule, config *Config) *pipeline {
+	t := sourceTuning(source, config)
+	detectors := ruleDetectorsWithConfidence(rules, config, t.RuleConfidence)
+	if config.EntropyThreshold > 0 {
+		detectors = append(detectors, entropyDetector{
+			threshold:  config.EntropyThreshold + t.EntropyOffset,
+			severity:   config.EntropySeverity,
+			confidence: t.EntropyConfidence,
+		})
+	}
+	p := &pipeline{rules: rules, config: config, tuning: t, detectors: detectors}
+	p.start()
+	return p
+}
+
+// start begins a new input: key blocks are parsed and rule context is looked
+// for across its lines
+func (p *pipeline) start() {
+	p.findings = nil
+	p.stages = []lineStage{newKeyBlocks(p.rules, p.config), newContextLines(p.rules)}
+}
+
+// scan feeds the next chunk of the current input and returns its findings.
+// The line stages may still change or drop them until finish.
+func (p *pipeline) scan(chunk Chunk) []Finding {
+	start := len(p.findings)
+	trim := strings.TrimSpace(chunk.Text)
+	if trim != "" && !(p.tuning.SkipComments && isCommentLine(trim)) {
+		p.findings = append(p.findings, runDetectors(chunk, p.detectors, p.config)...)
+		if p.tuning.DecodeBlobs {
+			p.findings = append(p.findings, scanEncodedBlobs(chunk, p.rules, p.config)...)
+		}
+	}
+	// the stages see every line, as key blocks and context can be in
+	// comments
+	for _, s := range p.stages {
+		s.scan(chunk, p.findings)
+	}
+	return p.findings[start:len(p.findings):len(p.findings)]
+}
+
+// finish ends the current input, returning its findings, and starts the next
+func (p *pipeline) finish() []Finding {
+	findings := p.findings
+	for _, s := range p.stages {
+		findings = s.finish(findings)
+	}
+	p.start()
+	return findings
+}
+
+// isCommentLine is a basic check for a line that is only a comment
+func isCommentLine(trim string) bool {
+	return strings.HasPrefix(trim, "//") || strings.HasPrefix(trim, "#") ||
+		strings.HasPrefix(trim, "/*") || strings.HasPrefix(trim, "*")
+}
+
+// lineChunks reads content line by line, calling fn with a chunk per line
+func lineChunks(path string, in io.Reader, fn func(Chunk)) error {
+	r := bufio.NewReader(in)
+	lineNo := 0
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		lineNo++
+		if line != "" {
+			fn(Chunk{Path: path, Line: lineNo, Text: line})
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
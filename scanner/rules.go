@@ -0,0 +1,175 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Config holds scanner configuration
+type Config struct {
+	Rules             map[string]*Rule
+	Detectors         []Detector              // run alongside the regex rules
+	SourceTuning      map[string]SourceTuning // by Source kind; missing kinds use DefaultSourceTuning
+	SkipDirs          []string
+	SkipFiles         []string
+	AllowPatterns     []*regexp.Regexp
+	EntropyThreshold  float64
+	EntropySeverity   string
+	Severities        map[string]string // overrides for detectors that are not regex rules
+	MaxDecodeDepth    int
+	ScanArchives      bool
+	MaxArchiveDepth   int
+	MaxArchiveSize    int64 // uncompressed bytes read per archive
+	MinSecretLength   int
+	MaxSecretLength   int
+	Verbose           bool
+	RespectGitignore  bool
+	GitignorePatterns []GitignorePattern
+
+	// Logf receives the diagnostics reported when Verbose is set, such as
+	// skipped paths; nil writes them to standard error
+	Logf func(format string, args ...interface{})
+
+	keys *keyIndex // public keys and certificates seen so far, for Scanner.LinkKeys
+}
+
+// logf reports a diagnostic when Verbose is set. Reports go to Logf or
+// standard error, never to standard output, which may carry a report.
+func (c *Config) logf(format string, args ...interface{}) {
+	if !c.Verbose {
+		return
+	}
+	if c.Logf != nil {
+		c.Logf(format, args...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
+// Rule represents a detection rule
+type Rule struct {
+	Name        string
+	Pattern     *regexp.Regexp
+	Keywords    []string
+	Description string
+	Severity    string
+	Confidence  float64
+	Enabled     bool
+	// SkipAllowPatterns exempts matches from the allow patterns, for token
+	// formats such as URLs that the generic patterns would misjudge
+	SkipAllowPatterns bool
+	// Context, when set, is required near a match for it to be trusted
+	Context *RuleContext
+}
+
+// severityOverridden reports whether [severity] set the rule's severity, which
+// then takes precedence over what its matches say about themselves
+func (r *Rule) severityOverridden() bool {
+	return r.Severity != RuleSeverity(r.Name)
+}
+
+// Human-readable descriptions for the detectors that are not catalogue rules
+var defaultRuleDescriptions = map[string]string{
+	"high_entropy":      "High-entropy string that may be a secret",
+	"structured_secret": "Secret-like key with a literal value in a structured config file",
+	"kubernetes_secret": "Value committed in a Kubernetes Secret manifest",
+	"terraform_state":   "Terraform state file committed to the repository",
+	"terraform_secret":  "Sensitive attribute or output stored in Terraform state",
+}
+
+// DetectorNames returns the names findings of the built-in detectors that are
+// not regex rules are reported under, sorted
+func DetectorNames() []string {
+	names := make([]string, 0, len(defaultRuleDescriptions))
+	for name := range defaultRuleDescriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RuleDescription returns the description for a rule name, falling back to the name itself
+func RuleDescription(name string) string {
+	if r, ok := builtinRules[name]; ok {
+		return r.Description
+	}
+	if d, ok := defaultRuleDescriptions[name]; ok {
+		return d
+	}
+	return name
+}
+
+// Patterns that should be allowed (common false positives)
+var defaultAllowPatterns = []string{
+	`^[A-Z_]+$`,                         // All caps constants
+	`^[a-z_]+$`,                         // All lowercase
+	`(?i)^(true|false|null|undefined)$`, // Boolean/null values
+	`^[\d.]+$`,                          // Pure numbers
+	`^https?://`,                        // URLs without credentials
+	`^[A-Za-z]+\.[A-Za-z]+`,             // Class/module names
+	`(?i)^(test|example|sample|demo|placeholder|your[_-].*|my[_-].*)`, // Test values
+	`^[*]+$`, // Masked secrets
+}
+
+func CompileRules(rules map[string]string) (map[string]*Rule, error) {
+	out := make(map[string]*Rule, len(rules))
+	for k, v := range rules {
+		r, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile %s: %w", k, err)
+		}
+		confidence := 0.9 // Default high confidence for regex patterns
+		if strings.HasPrefix(k, "generic_") {
+			confidence = 0.7 // Lower confidence for generic patterns
+		}
+		var keywords []string
+		var context *RuleContext
+		skipAllow := false
+		if builtin, ok := builtinRules[k]; ok && builtin.Regex == v {
+			// these only hold for the built-in regex, not a replacement
+			keywords, skipAllow = builtin.Keywords, builtin.SkipAllowPatterns
+			if builtin.Context != nil {
+				if context, err = compileRuleContext(*builtin.Context); err != nil {
+					return nil, fmt.Errorf("context for %s: %w", k, err)
+				}
+			}
+		}
+		out[k] = &Rule{
+			Name:              k,
+			Pattern:           r,
+			Keywords:          keywords,
+			SkipAllowPatterns: skipAllow,
+			Context:           context,
+			Description:       RuleDescription(k),
+			Severity:          RuleSeverity(k),
+			Confidence:        confidence,
+			Enabled:           true,
+		}
+	}
+	return out, nil
+}
+
+func compileAllowPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, p := range patterns {
+		r, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile allow pattern %s: %w", p, err)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// isAllowed checks if a value matches any allow pattern
+func isAllowed(value string, allowPatterns []*regexp.Regexp) bool {
+	for _, pattern := range allowPatterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
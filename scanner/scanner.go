@@ -0,0 +1,351 @@
+// Package scanner is the secscan detection engine. A Scanner runs the regex
+// rules, entropy and decoding checks and the structured-file, notebook,
+// archive and container image detectors over files, readers and git history.
+//
+//	s, err := scanner.New(scanner.WithEntropy(0), scanner.WithArchives(2, 100<<20))
+//	if err != nil {
+//		return err
+//	}
+//	findings, err := s.ScanPath(ctx, ".")
+//
+// Findings are returned unfiltered and may repeat across inputs; use
+// DeduplicateFindings to collapse them. Problems with individual files or
+// commits do not stop a scan and are recorded in Stats.
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Scanner scans inputs for secrets. Create one with New; a Scanner
+// accumulates Stats across scans and must not be used concurrently.
+type Scanner struct {
+	config     Config
+	rules      map[string]string // rule name -> regex, compiled by New
+	severities map[string]string // [severity] overrides by rule or detector name
+	contexts   map[string]RuleContext
+	allow      []string
+	stats      *Stats
+	onFindings func([]Finding)
+}
+
+// Option configures a Scanner
+type Option func(*Scanner)
+
+// WithRules replaces the default rules with name -> regex pairs
+func WithRules(rules map[string]string) Option {
+	return func(s *Scanner) { s.rules = rules }
+}
+
+// WithDetectors adds detectors that run alongside the regex rules on every
+// line of files, history and decoded blobs
+func WithDetectors(detectors ...Detector) Option {
+	return func(s *Scanner) { s.config.Detectors = append(s.config.Detectors, detectors...) }
+}
+
+// WithSeverities overrides the severity of rules and of the other detectors
+// (high_entropy, structured_secret, ...) by name
+func WithSeverities(severities map[string]string) Option {
+	return func(s *Scanner) { s.severities = severities }
+}
+
+// WithRuleContexts sets the context required near the matches of rules, by
+// rule name, replacing their DefaultRuleContexts
+func WithRuleContexts(contexts map[string]RuleContext) Option {
+	return func(s *Scanner) { s.contexts = contexts }
+}
+
+// WithAllowPatterns replaces the default allow patterns. Matched values that
+// also match an allow pattern are not reported, except for rules with
+// SkipAllowPatterns set.
+func WithAllowPatterns(patterns []string) Option {
+	return func(s *Scanner) { s.allow = patterns }
+}
+
+// WithEntropy sets the Shannon entropy threshold for high_entropy findings;
+// 0 disables entropy detection
+func WithEntropy(threshold float64) Option {
+	return func(s *Scanner) { s.config.EntropyThreshold = threshold }
+}
+
+// WithDecodeDepth sets how many nested base64/hex encodings are unwrapped; 0
+// disables decoding
+func WithDecodeDepth(depth int) Option {
+	return func(s *Scanner) { s.config.MaxDecodeDepth = depth }
+}
+
+// WithArchives enables scanning inside archives, opening at most maxDepth
+// nested archives and reading at most maxSize uncompressed bytes per archive
+func WithArchives(maxDepth int, maxSize int64) Option {
+	return func(s *Scanner) {
+		s.config.ScanArchives = true
+		s.config.MaxArchiveDepth = maxDepth
+		s.config.MaxArchiveSize = maxSize
+	}
+}
+
+// WithSourceTuning overrides the DefaultSourceTuning of the source kinds given
+func WithSourceTuning(tuning map[string]SourceTuning) Option {
+	return func(s *Scanner) {
+		if len(tuning) == 0 {
+			return
+		}
+		if s.config.SourceTuning == nil {
+			s.config.SourceTuning = make(map[string]SourceTuning)
+		}
+		for source, t := range tuning {
+			s.config.SourceTuning[source] = t
+		}
+	}
+}
+
+// WithGitignore sets whether ScanPath honours .gitignore files. patterns are
+// used for every path when given; otherwise they are collected from each
+// directory passed to ScanPath.
+func WithGitignore(respect bool, patterns []GitignorePattern) Option {
+	return func(s *Scanner) {
+		s.config.RespectGitignore = respect
+		s.config.GitignorePatterns = patterns
+	}
+}
+
+// WithVerbose reports skipped paths, to standard error unless WithLogf is
+// given
+func WithVerbose(verbose bool) Option {
+	return func(s *Scanner) { s.config.Verbose = verbose }
+}
+
+// WithLogf sends verbose diagnostics to logf, such as log.Printf, instead of
+// standard error
+func WithLogf(logf func(format string, args ...interface{})) Option {
+	return func(s *Scanner) { s.config.Logf = logf }
+}
+
+// WithFindingCallback calls fn with the findings of each file, archive, image
+// or commit as soon as it has been scanned, before the scan returns
+func WithFindingCallback(fn func([]Finding)) Option {
+	return func(s *Scanner) { s.onFindings = fn }
+}
+
+// New creates a Scanner with the default rules and settings, then applies opts
+func New(opts ...Option) (*Scanner, error) {
+	s := &Scanner{
+		config: Config{
+			EntropyThreshold: 5.0,
+			MaxDecodeDepth:   DefaultMaxDecodeDepth,
+			MaxArchiveDepth:  DefaultMaxArchiveDepth,
+			MaxArchiveSize:   DefaultMaxArchiveSizeMB << 20,
+			MinSecretLength:  8,
+			MaxSecretLength:  512,
+			RespectGitignore: true,
+			keys:             newKeyIndex(),
+		},
+		rules: defaultRegexps,
+		allow: defaultAllowPatterns,
+		stats: &Stats{StartTime: time.Now()},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	compiled, err := CompileRules(s.rules)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySeverities(compiled, s.severities); err != nil {
+		return nil, err
+	}
+	if err := applyRuleContexts(compiled, s.contexts); err != nil {
+		return nil, err
+	}
+	s.config.Rules = compiled
+
+	// Severities not naming a rule apply to the other detectors; a name
+	// matching neither is most likely a typo
+	detectors := make(map[string]bool)
+	for _, name := range DetectorNames() {
+		detectors[name] = true
+	}
+	for _, d := range s.config.Detectors {
+		detectors[d.Name()] = true
+	}
+	s.config.EntropySeverity = RuleSeverity("high_entropy")
+	s.config.Severities = make(map[string]string)
+	for name, v := range s.severities {
+		if _, ok := compiled[name]; ok {
+			continue
+		}
+		if !detectors[name] {
+			return nil, fmt.Errorf("severity for %s: no rule or detector has that name", name)
+		}
+		sev, err := ParseSeverity(v, false)
+		if err != nil {
+			return nil, fmt.Errorf("severity for %s: %w", name, err)
+		}
+		if name == "high_entropy" {
+			s.config.EntropySeverity = sev
+		}
+		s.config.Severities[name] = sev
+	}
+
+	s.config.AllowPatterns, err = compileAllowPatterns(s.allow)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// DefaultRules returns a copy of the built-in rules as name -> regex pairs
+func DefaultRules() map[string]string {
+	out := make(map[string]string, len(defaultRegexps))
+	for k, v := range defaultRegexps {
+		out[k] = v
+	}
+	return out
+}
+
+// Rules returns the compiled rules by name
+func (s *Scanner) Rules() map[string]*Rule {
+	return s.config.Rules
+}
+
+// LinkKeys returns copies of findings in which each parsed private key is
+// linked to the public key and certificate with the same fingerprint seen by
+// the scans so far, adding public_key_path, certificate_path,
+// certificate_subject, certificate_dns_names and certificate_not_after to its
+// metadata. Call it once every input has been scanned.
+func (s *Scanner) LinkKeys(findings []Finding) []Finding {
+	return linkKeys(findings, s.config.keys)
+}
+
+// Stats returns the statistics accumulated by the scans so far
+func (s *Scanner) Stats() *Stats {
+	return s.stats
+}
+
+// interrupted returns ctx's error once ctx is done, recording in Stats that
+// the scan stopped early
+func (s *Scanner) interrupted(ctx context.Context) error {
+	err := ctx.Err()
+	if err != nil {
+		s.stats.interrupted(err)
+	}
+	return err
+}
+
+// record counts one scanned input and passes its findings to the callback.
+// An input cut short by ctx keeps its findings but is not counted as scanned.
+func (s *Scanner) record(ctx context.Context, name string, findings []Finding, err error) {
+	stopped := s.interrupted(ctx) != nil
+	if err != nil && !stopped {
+		// keep going, but record the input that could not be read
+		s.stats.AddError(ScanError{Path: name, Op: OpRead, Message: err.Error()})
+		if len(findings) == 0 {
+			return
+		}
+	}
+	if len(findings) > 0 {
+		s.stats.incrementFindings(len(findings))
+		if s.onFindings != nil {
+			s.onFindings(findings)
+		}
+	}
+	if !stopped {
+		s.stats.incrementFiles()
+	}
+}
+
+// ScanPath scans a file, or walks a directory and scans the text files in it.
+// Archives are opened when enabled with WithArchives. Files that cannot be
+// read are recorded in Stats and skipped; the returned error is set when path
+// itself cannot be scanned, or when ctx is done, along with the findings so
+// far.
+func (s *Scanner) ScanPath(ctx context.Context, path string) ([]Finding, error) {
+	if err := s.interrupted(ctx); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		s.stats.AddError(ScanError{Path: path, Op: OpRead, Message: err.Error()})
+		return nil, err
+	}
+
+	var findings []Finding
+	scanFile := func(name string) error {
+		var fnds []Finding
+		var err error
+		if s.config.ScanArchives && archiveKind(name) != "" {
+			fnds, err = scanArchiveFile(ctx, name, s.config.Rules, &s.config, s.stats)
+			if err != nil && ctx.Err() == nil {
+				s.stats.AddError(ScanError{Path: name, Op: OpArchive, Message: err.Error()})
+			}
+			err = nil
+		} else {
+			fnds, err = scanFileForSecrets(ctx, name, s.config.Rules, &s.config)
+		}
+		s.record(ctx, name, fnds, err)
+		findings = append(findings, fnds...)
+		return ctx.Err()
+	}
+
+	if info.IsDir() {
+		config := s.config
+		if config.RespectGitignore && config.GitignorePatterns == nil {
+			config.GitignorePatterns = CollectGitignorePatterns(path)
+		}
+		_ = walkFiles(ctx, path, &config, s.stats, scanFile)
+	} else {
+		_ = scanFile(path)
+	}
+	return findings, s.interrupted(ctx)
+}
+
+// ScanReader scans content read from r, reporting findings against name.
+// The name's extension selects structured-file and notebook parsing.
+func (s *Scanner) ScanReader(ctx context.Context, name string, r io.Reader) ([]Finding, error) {
+	if err := s.interrupted(ctx); err != nil {
+		return nil, err
+	}
+	findings, err := scanReaderForSecrets(ctx, SourceStdin, name, r, s.config.Rules, &s.config)
+	s.record(ctx, name, findings, err)
+	return findings, err
+}
+
+// ScanImage scans a docker save tarball or OCI image layout, layer by layer,
+// along with the image config's Env and history
+func (s *Scanner) ScanImage(ctx context.Context, name string) ([]Finding, error) {
+	if err := s.interrupted(ctx); err != nil {
+		return nil, err
+	}
+	findings, err := scanImage(ctx, name, s.config.Rules, &s.config, s.stats)
+	if err != nil && ctx.Err() == nil {
+		s.stats.AddError(ScanError{Path: name, Op: OpImage, Message: err.Error()})
+	}
+	s.record(ctx, name, findings, nil)
+	return findings, err
+}
+
+// ScanHistory scans the added and removed lines of every commit selected by
+// opts in the git repository at repo (the current directory when empty).
+// Commits that cannot be read are recorded in Stats and skipped. When ctx is
+// done, git is stopped and the findings of the commits scanned so far are
+// returned with ctx's error.
+//
+// Without git or a repository at repo it returns ErrNoHistory, which is not
+// recorded in Stats: callers that require history record it themselves.
+func (s *Scanner) ScanHistory(ctx context.Context, repo string, opts HistoryOptions) ([]Finding, error) {
+	if err := s.interrupted(ctx); err != nil {
+		return nil, err
+	}
+	findings, err := scanGitHistory(ctx, repo, opts, s.config.Rules, &s.config, s.stats, s.onFindings)
+	if s.interrupted(ctx) == nil && err != nil && !errors.Is(err, ErrNoHistory) {
+		s.stats.AddError(ScanError{Op: OpHistory, Message: err.Error()})
+	}
+	s.stats.incrementFindings(len(findings))
+	return findings, err
+}
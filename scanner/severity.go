@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity levels, from most to least severe
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+
+	// SeverityNone is only valid as a -fail-on threshold and never fails a scan
+	SeverityNone = "none"
+)
+
+// severityRanks orders severity levels; higher is more severe
+var severityRanks = map[string]int{
+	SeverityNone:     0,
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// Default severity of the detectors that are not catalogue rules; catalogue
+// rules carry their own. Severity describes the impact of a leaked secret
+// of that kind, independent of how confident the match is.
+var defaultRuleSeverities = map[string]string{
+	"high_entropy":      SeverityMedium,
+	"structured_secret": SeverityMedium,
+	"kubernetes_secret": SeverityHigh,
+	"terraform_state":   SeverityHigh,
+	"terraform_secret":  SeverityCritical,
+}
+
+// SeverityRank returns the rank of a severity level, higher being more
+// severe. Unknown levels and "none" rank 0.
+func SeverityRank(severity string) int {
+	return severityRanks[severity]
+}
+
+// RuleSeverity returns the default severity for a rule name. Custom rules
+// without a configured severity are treated as high, generic ones as medium.
+func RuleSeverity(name string) string {
+	if r, ok := builtinRules[name]; ok {
+		return r.Severity
+	}
+	if s, ok := defaultRuleSeverities[name]; ok {
+		return s
+	}
+	if strings.HasPrefix(name, "generic_") {
+		return SeverityMedium
+	}
+	return SeverityHigh
+}
+
+// ParseSeverity normalizes a severity name. allowNone permits "none", which is
+// only meaningful as a -fail-on threshold.
+func ParseSeverity(s string, allowNone bool) (string, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	if v == SeverityNone && !allowNone {
+		return "", fmt.Errorf("invalid severity %q (want critical, high, medium or low)", s)
+	}
+	if _, ok := severityRanks[v]; !ok {
+		if allowNone {
+			return "", fmt.Errorf("invalid severity %q (want critical, high, medium, low or none)", s)
+		}
+		return "", fmt.Errorf("invalid severity %q (want critical, high, medium or low)", s)
+	}
+	return v, nil
+}
+
+// applySeverities overrides rule severities from a name -> severity map
+func applySeverities(rules map[string]*Rule, severities map[string]string) error {
+	for name, value := range severities {
+		sev, err := ParseSeverity(value, false)
+		if err != nil {
+			return fmt.Errorf("severity for %s: %w", name, err)
+		}
+		if rule, ok := rules[name]; ok {
+			rule.Severity = sev
+		}
+	}
+	return nil
+}
+
+// SeverityCounts holds the number of findings per severity level
+type SeverityCounts struct {
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
+}
+
+// CountSeverities groups findings by severity
+func CountSeverities(findings []Finding) SeverityCounts {
+	var c SeverityCounts
+	for _, f := range findings {
+		switch f.Severity {
+		case SeverityCritical:
+			c.Critical++
+		case SeverityHigh:
+			c.High++
+		case SeverityMedium:
+			c.Medium++
+		default:
+			c.Low++
+		}
+	}
+	return c
+}
+
+// detectorSeverity returns the severity for findings of a detector that is not
+// a regex rule, honouring [severity] overrides from the config file
+func (c *Config) detectorSeverity(name string) string {
+	if s, ok := c.Severities[name]; ok {
+		return s
+	}
+	return RuleSeverity(name)
+}
@@ -0,0 +1,19 @@
+package scanner
+
+import "testing"
+
+// TestParseSeverity verifies severity name validation
+func TestParseSeverity(t *testing.T) {
+	if got, err := ParseSeverity(" HIGH ", false); err != nil || got != SeverityHigh {
+		t.Errorf("ParseSeverity(HIGH) = %q, %v", got, err)
+	}
+	if _, err := ParseSeverity("none", false); err == nil {
+		t.Error("none should only be accepted as a -fail-on threshold")
+	}
+	if _, err := ParseSeverity("none", true); err != nil {
+		t.Errorf("none should be accepted as a threshold: %v", err)
+	}
+	if _, err := ParseSeverity("urgent", true); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
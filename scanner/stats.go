@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"sync"
+	"time"
+)
+
+// Stats tracks scanning statistics
+type Stats struct {
+	FilesScanned   int
+	CommitsScanned int
+	FindingsTotal  int
+	FindingsUnique int
+	FilesSkipped   int
+	DirsSkipped    int
+	SkipReasons    map[string]int
+	Errors         []ScanError
+	StartTime      time.Time
+	EndTime        time.Time
+	mu             sync.Mutex
+}
+
+// ScanError records a path or commit that could not be fully scanned
+type ScanError struct {
+	Path    string `json:"path,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	Op      string `json:"op"`
+	Message string `json:"message"`
+}
+
+// Operations reported in ScanError.Op
+const (
+	OpWalk    = "walk"
+	OpRead    = "read"
+	OpHistory = "history"
+	OpGitShow = "git-show"
+	OpArchive = "archive"
+	OpImage   = "image"
+
+	// OpInterrupted records that the scan stopped early, on cancellation or timeout
+	OpInterrupted = "interrupted"
+)
+
+func (e ScanError) Error() string {
+	target := e.Path
+	if e.Commit != "" {
+		target = "commit " + e.Commit
+	}
+	if target == "" {
+		return e.Op + ": " + e.Message
+	}
+	return e.Op + " " + target + ": " + e.Message
+}
+
+func (s *Stats) incrementFiles() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.FilesScanned++
+}
+
+func (s *Stats) incrementCommits() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.CommitsScanned++
+}
+
+func (s *Stats) incrementFindings(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.FindingsTotal += count
+}
+
+func (s *Stats) AddError(e ScanError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Errors = append(s.Errors, e)
+}
+
+// interrupted records, once, that the scan was stopped by err
+func (s *Stats) interrupted(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.Errors {
+		if e.Op == OpInterrupted {
+			return
+		}
+	}
+	s.Errors = append(s.Errors, ScanError{Op: OpInterrupted, Message: err.Error()})
+}
+
+// Incomplete reports whether the scan failed to cover what was asked of it:
+// nothing at all was scanned, the history scan could not run, or the scan was
+// interrupted
+func (s *Stats) Incomplete() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.Errors) == 0 {
+		return false
+	}
+	if s.FilesScanned == 0 && s.CommitsScanned == 0 {
+		return true
+	}
+	for _, e := range s.Errors {
+		if e.Op == OpHistory || e.Op == OpInterrupted {
+			return true
+		}
+	}
+	return false
+}
+
+// Reasons a path was skipped, reported in Stats.SkipReasons
+const (
+	SkipReasonGitignore = "gitignore"
+	SkipReasonDirectory = "skip_directory"
+	SkipReasonFileType  = "file_type"
+	SkipReasonHistory   = "history_file_type"
+
+	// SkipReasonArchiveLimit covers archive entries over the size or depth limits
+	SkipReasonArchiveLimit = "archive_limit"
+)
+
+func (s *Stats) incrementSkipped(reason string, dir bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.SkipReasons == nil {
+		s.SkipReasons = make(map[string]int)
+	}
+	s.SkipReasons[reason]++
+	if dir {
+		s.DirsSkipped++
+	} else {
+		s.FilesSkipped++
+	}
+}
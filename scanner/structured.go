@@ -0,0 +1,448 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// structuredRuleName is the pattern name of key/value findings from structured files
+const structuredRuleName = "structured_secret"
+
+// Structured file formats
+const (
+	formatYAML       = "yaml"
+	formatJSON       = "json"
+	formatEnv        = "env"
+	formatINI        = "ini"
+	formatProperties = "properties"
+	formatHelmValues = "helm_values"
+
+	formatTerraformState = "tfstate"
+	formatTFVars         = "tfvars"
+)
+
+// keyValue is a scalar value found in a structured file, addressed by its
+// dotted key path (e.g. spring.datasource.password or servers[0].token)
+type keyValue struct {
+	Path  string
+	Value string
+	Line  int
+}
+
+// secretKeyPattern matches the last segment of key names that hold secrets.
+// It is anchored at the end so password_policy or token_url do not match.
+var secretKeyPattern = regexp.MustCompile(`(?i)(password|passwd|passphrase|pwd|secret|token|api[_\-]?key|(access|auth|private|secret|signing|encryption|master|client)[_\-]?key|credentials?)$`)
+
+// placeholderValuePattern matches values that are clearly not real secrets
+var placeholderValuePattern = regexp.MustCompile(`(?i)^(changeme|change[_\-]?me|replace[_\-]?me|password|secret|token|redacted|none|null|nil|~|undefined|true|false|todo|tbd|dummy|fake|x+|\*+|\.+|example.*|sample.*|test.*|your[_\-].*|my[_\-].*|<.*>|\[.*\])$`)
+
+// minStructuredValueLength is the shortest value reported for a secret-like key
+const minStructuredValueLength = 6
+
+// isEnvFile reports whether a file name is a dotenv file (.env, .env.local, prod.env)
+func isEnvFile(base string) bool {
+	return base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// structuredFormat returns the structured format of a file from its name, or ""
+func structuredFormat(name string) string {
+	base := strings.ToLower(filepath.Base(name))
+	if isEnvFile(base) {
+		return formatEnv
+	}
+	if isTerraformStateFile(base) {
+		return formatTerraformState
+	}
+	if strings.HasSuffix(base, ".tfvars") {
+		return formatTFVars
+	}
+	switch filepath.Ext(base) {
+	case ".yaml", ".yml":
+		if strings.HasPrefix(base, "values") {
+			return formatHelmValues
+		}
+		return formatYAML
+	case ".json":
+		return formatJSON
+	case ".ini", ".cfg", ".conf":
+		return formatINI
+	case ".properties":
+		return formatProperties
+	}
+	return ""
+}
+
+// parseStructured extracts the scalar key/values of a structured file
+func parseStructured(format string, data []byte) ([]keyValue, error) {
+	switch format {
+	case formatYAML, formatHelmValues:
+		docs, err := parseYAML(data)
+		if err != nil {
+			return nil, err
+		}
+		var out []keyValue
+		for _, doc := range docs {
+			flattenYAML(doc, "", &out)
+		}
+		return out, nil
+	case formatJSON, formatTerraformState:
+		return parseJSONKeyValues(data)
+	case formatTFVars:
+		return parseTFVarsKeyValues(data), nil
+	case formatEnv:
+		return parseEnvKeyValues(data), nil
+	case formatINI:
+		return parseINIKeyValues(data), nil
+	case formatProperties:
+		return parsePropertiesKeyValues(data), nil
+	}
+	return nil, fmt.Errorf("unknown structured format %q", format)
+}
+
+// joinKeyPath appends a key to a dotted key path
+func joinKeyPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// flattenYAML collects the scalars of a YAML node tree
+func flattenYAML(n *yamlNode, path string, out *[]keyValue) {
+	switch n.Kind {
+	case yamlScalar:
+		*out = append(*out, keyValue{Path: path, Value: n.Value, Line: n.Line})
+	case yamlMapping:
+		for _, p := range n.Pairs {
+			flattenYAML(p.Value, joinKeyPath(path, p.Key), out)
+		}
+	case yamlSequence:
+		for i, item := range n.Items {
+			flattenYAML(item, fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	}
+}
+
+// parseJSONKeyValues walks a JSON document and collects its string values
+func parseJSONKeyValues(data []byte) ([]keyValue, error) {
+	// Offsets of line starts, to turn decoder offsets into line numbers
+	lineStarts := []int{0}
+	for i, b := range data {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	lineAt := func(offset int64) int {
+		return sort.SearchInts(lineStarts, int(offset)+1)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var out []keyValue
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				for dec.More() {
+					keyTok, err := dec.Token()
+					if err != nil {
+						return err
+					}
+					key, _ := keyTok.(string)
+					if err := walk(joinKeyPath(path, key)); err != nil {
+						return err
+					}
+				}
+			case '[':
+				for i := 0; dec.More(); i++ {
+					if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+						return err
+					}
+				}
+			}
+			_, err = dec.Token() // closing delimiter
+			return err
+		case string:
+			out = append(out, keyValue{Path: path, Value: t, Line: lineAt(dec.InputOffset())})
+		}
+		return nil
+	}
+
+	for {
+		if err := walk(""); err != nil {
+			if err == io.EOF {
+				return out, nil
+			}
+			return out, err
+		}
+	}
+}
+
+// unquoteConfigValue strips matching quotes, or a trailing " #" comment from an unquoted value
+func unquoteConfigValue(v string, comments string) string {
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') {
+		if end := strings.IndexByte(v[1:], v[0]); end >= 0 {
+			return v[1 : end+1]
+		}
+		return v
+	}
+	for _, c := range comments {
+		if i := strings.Index(v, " "+string(c)); i >= 0 {
+			v = v[:i]
+		}
+	}
+	return strings.TrimSpace(v)
+}
+
+// parseEnvKeyValues parses KEY=value lines of a dotenv file
+func parseEnvKeyValues(data []byte) []keyValue {
+	var out []keyValue
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(make([]byte, 64*1024), maxEncodedBlobSize)
+	for lineNo := 1; s.Scan(); lineNo++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.IndexByte(line, '=')
+		if i <= 0 {
+			continue
+		}
+		out = append(out, keyValue{
+			Path:  strings.TrimSpace(line[:i]),
+			Value: unquoteConfigValue(line[i+1:], "#"),
+			Line:  lineNo,
+		})
+	}
+	return out
+}
+
+// parseINIKeyValues parses key = value lines, prefixing keys with their [section]
+func parseINIKeyValues(data []byte) []keyValue {
+	var out []keyValue
+	section := ""
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(make([]byte, 64*1024), maxEncodedBlobSize)
+	for lineNo := 1; s.Scan(); lineNo++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i <= 0 {
+			continue
+		}
+		out = append(out, keyValue{
+			Path:  joinKeyPath(section, strings.TrimSpace(line[:i])),
+			Value: unquoteConfigValue(line[i+1:], "#;"),
+			Line:  lineNo,
+		})
+	}
+	return out
+}
+
+// parsePropertiesKeyValues parses a Java .properties file, including
+// backslash line continuations
+func parsePropertiesKeyValues(data []byte) []keyValue {
+	var out []keyValue
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(make([]byte, 64*1024), maxEncodedBlobSize)
+	for lineNo := 0; s.Scan(); {
+		lineNo++
+		start := lineNo
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		for strings.HasSuffix(line, `\`) && s.Scan() {
+			lineNo++
+			line = line[:len(line)-1] + strings.TrimSpace(s.Text())
+		}
+
+		// The key ends at the first unescaped '=', ':' or whitespace
+		end := len(line)
+		for i := 0; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+				continue
+			}
+			if line[i] == '=' || line[i] == ':' || line[i] == ' ' || line[i] == '\t' {
+				end = i
+				break
+			}
+		}
+		key := strings.NewReplacer(`\:`, ":", `\=`, "=", `\ `, " ").Replace(line[:end])
+		value := strings.TrimLeft(line[end:], " \t")
+		if strings.HasPrefix(value, "=") || strings.HasPrefix(value, ":") {
+			value = strings.TrimSpace(value[1:])
+		}
+		out = append(out, keyValue{Path: key, Value: value, Line: start})
+	}
+	return out
+}
+
+// isSecretKey reports whether the end of a key path names a secret. The last
+// two segments are also tried together, so app.secret.key reads as secret_key.
+func isSecretKey(path string) bool {
+	if strings.HasSuffix(path, "]") {
+		// keys such as "matrix]" end in a bracket without an index
+		if i := strings.LastIndexByte(path, '['); i >= 0 {
+			path = path[:i]
+		}
+	}
+	segments := strings.Split(path, ".")
+	last := segments[len(segments)-1]
+	if secretKeyPattern.MatchString(last) {
+		return true
+	}
+	return len(segments) > 1 && secretKeyPattern.MatchString(segments[len(segments)-2]+"_"+last)
+}
+
+// isPlaceholderValue reports whether a value is empty, a template reference or
+// an obvious placeholder rather than a literal secret
+func isPlaceholderValue(v string) bool {
+	v = strings.TrimSpace(v)
+	if len(v) < minStructuredValueLength || strings.ContainsAny(v, " \t\n") {
+		return true
+	}
+	for _, ref := range []string{"${", "{{", "$(", "%(", "#{"} {
+		if strings.Contains(v, ref) {
+			return true
+		}
+	}
+	if strings.HasPrefix(v, "$") || strings.HasPrefix(v, "/") || strings.HasPrefix(v, "./") {
+		return true
+	}
+	if (strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://")) && !strings.Contains(v, "@") {
+		return true
+	}
+	if strings.Trim(v, "0123456789.") == "" || strings.Trim(v, v[:1]) == "" {
+		return true
+	}
+	return placeholderValuePattern.MatchString(v)
+}
+
+// structuredCandidate is a value proposed for reporting by a structured parser
+type structuredCandidate struct {
+	keyValue
+	Pattern  string
+	Metadata map[string]string
+	Label    string // names the value in the excerpt; defaults to Path
+
+	// Multiline values (files stored in a Secret) are secret material as a
+	// whole and are not subject to the single-line placeholder checks
+	Multiline bool
+}
+
+// keyValueCandidates keeps the pairs whose key names a secret
+func keyValueCandidates(pairs []keyValue, metadata map[string]string) []structuredCandidate {
+	var out []structuredCandidate
+	for _, kv := range pairs {
+		if isSecretKey(kv.Path) {
+			out = append(out, structuredCandidate{keyValue: kv, Pattern: structuredRuleName, Metadata: metadata})
+		}
+	}
+	return out
+}
+
+// scanStructured parses a structured file and reports secret-like keys with
+// literal values, returning findings extended with the new ones. Lines already
+// reported by a generic rule, or whose value was already matched by a specific
+// rule, are skipped. Files that fail to parse (templated YAML, JSON with
+// comments) are left to the line scanner.
+func scanStructured(path, format string, data []byte, findings []Finding, config *Config) []Finding {
+	var candidates []structuredCandidate
+	if format == formatYAML || format == formatHelmValues {
+		docs, err := parseYAML(data)
+		if err != nil {
+			return findings
+		}
+		for i, doc := range docs {
+			end := 0
+			if i+1 < len(docs) {
+				end = docs[i+1].Line - 1
+			}
+			var c []structuredCandidate
+			c, findings = scanYAMLDocument(format, doc, i, end, findings)
+			candidates = append(candidates, c...)
+		}
+	} else if format == formatTerraformState {
+		candidates, findings = scanTerraformState(path, data, findings, config)
+	} else {
+		pairs, err := parseStructured(format, data)
+		if err != nil && len(pairs) == 0 {
+			return findings
+		}
+		candidates = keyValueCandidates(pairs, nil)
+	}
+	return reportCandidates(path, format, candidates, findings, config)
+}
+
+// reportCandidates appends a finding for each candidate that is not a
+// placeholder and not already covered by a finding on the same line
+func reportCandidates(path, format string, candidates []structuredCandidate, findings []Finding, config *Config) []Finding {
+	byLine := make(map[int][]Finding)
+	for _, f := range findings {
+		byLine[f.Line] = append(byLine[f.Line], f)
+	}
+
+	for _, c := range candidates {
+		if !(c.Multiline && strings.Contains(strings.TrimSpace(c.Value), "\n")) && isPlaceholderValue(c.Value) {
+			continue
+		}
+
+		covered := false
+		for _, f := range byLine[c.Line] {
+			if strings.HasPrefix(f.Pattern, "generic_") || strings.Contains(c.Value, f.RawValue) || strings.Contains(f.RawValue, c.Value) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+
+		label := c.Label
+		if label == "" {
+			label = c.Path
+		}
+		metadata := map[string]string{
+			"key_path": c.Path,
+			"format":   format,
+		}
+		for k, v := range c.Metadata {
+			metadata[k] = v
+		}
+		findings = append(findings, Finding{
+			File:       path,
+			Line:       c.Line,
+			Pattern:    c.Pattern,
+			Severity:   config.detectorSeverity(c.Pattern),
+			Excerpt:    label + ": " + maskSecret(strings.TrimSpace(c.Value)),
+			RawValue:   c.Value,
+			Confidence: 0.75,
+			Verified:   false,
+			Metadata:   metadata,
+			Hash:       generateHash(path, c.Pattern, c.Path+"="+c.Value),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestScanStructured verifies key-path reporting per format
+func TestScanStructured(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		line    int
+	}{
+		{"application.yaml", "spring:\n  datasource:\n    password: hunter2hunter2\n", "spring.datasource.password", 3},
+		{".env", "# db\nexport DB_PASSWORD=Pa55w0rdXYZ # prod\n", "DB_PASSWORD", 2},
+		{"creds.json", "{\n  \"oauth\": {\"client_secret\": \"zx81Qp0LmN3t\"}\n}\n", "oauth.client_secret", 2},
+		{"setup.ini", "[database]\npassword = iniPass9876\n", "database.password", 2},
+		{"app.properties", "app.secret.key=prop_key_998877\n", "app.secret.key", 1},
+		{"list.yml", "users:\n  - name: a\n    token: q8ZrT1vX9kLm\n", "users[0].token", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := scanReaderForSecrets(context.Background(), SourceStdin, tt.name, strings.NewReader(tt.content), nil, &Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(findings) != 1 {
+				t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+			}
+			f := findings[0]
+			if f.Pattern != structuredRuleName || f.Metadata["key_path"] != tt.path || f.Line != tt.line {
+				t.Errorf("got %s %q at line %d, want %q at line %d", f.Pattern, f.Metadata["key_path"], f.Line, tt.path, tt.line)
+			}
+		})
+	}
+}
+
+// TestScanStructuredPlaceholders verifies that references and placeholders are ignored
+func TestScanStructuredPlaceholders(t *testing.T) {
+	content := `password: ${DB_PASSWORD}
+api_key: changeme
+secret: "<your-secret>"
+token_url: https://example.com/oauth/token
+client_secret: xxxxxxxx
+passphrase: ""
+`
+	findings, err := scanReaderForSecrets(context.Background(), SourceStdin, "values.yaml", strings.NewReader(content), nil, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("expected no findings, got %+v", findings)
+	}
+}
+
+// TestScanStructuredSkipsCoveredLines verifies that lines already matched by a rule are not reported twice
+func TestScanStructuredSkipsCoveredLines(t *testing.T) {
+	rules, err := CompileRules(map[string]string{"generic_secret": defaultRegexps["generic_secret"]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err := scanReaderForSecrets(context.Background(), SourceStdin, "config.yaml", strings.NewReader("password: \"hunter2hunter2\"\n"), rules, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Pattern != "generic_secret" {
+		t.Errorf("expected a single generic_secret finding, got %+v", findings)
+	}
+}
+
+// FuzzScanStructured verifies that no input crashes the structured parsers;
+// the seeds include keys that end in a bracket without opening one
+func FuzzScanStructured(f *testing.F) {
+	seeds := []struct{ name, content string }{
+		{"ci.yaml", "matrix]: abc\n"},
+		{"data.json", `{"a]": "x"}`},
+		{"app.properties", "k]=v\n"},
+		{"list.yml", "users:\n  - token: q8ZrT1vX9kLm\n"},
+		{".env", "TOKEN]=abc123\n"},
+		{"setup.ini", "[db\npassword] = x\n"},
+	}
+	for _, s := range seeds {
+		f.Add(s.name, s.content)
+	}
+	f.Fuzz(func(t *testing.T, name, content string) {
+		if _, err := scanReaderForSecrets(context.Background(), SourceStdin, name, strings.NewReader(content), nil, &Config{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,388 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Pattern names of Terraform findings
+const (
+	terraformStateRuleName  = "terraform_state"
+	terraformSecretRuleName = "terraform_secret"
+)
+
+// terraformSensitiveAttributes are attribute names that providers store in
+// plain text in state even when they are not marked sensitive
+var terraformSensitiveAttributes = map[string]bool{
+	"private_key_pem":           true,
+	"private_key_openssh":       true,
+	"private_key_pem_pkcs8":     true,
+	"client_key":                true,
+	"master_password":           true,
+	"admin_password":            true,
+	"connection_string":         true,
+	"primary_access_key":        true,
+	"secondary_access_key":      true,
+	"primary_connection_string": true,
+	"ses_smtp_password_v4":      true,
+	"kube_config_raw":           true,
+}
+
+// isTerraformStateFile reports whether a file name is a Terraform state file
+// or one of its backups (terraform.tfstate.backup, terraform.tfstate.1699.backup)
+func isTerraformStateFile(base string) bool {
+	return strings.HasSuffix(base, ".tfstate") || strings.Contains(base, ".tfstate.")
+}
+
+// terraformState is the subset of the Terraform state (version 4) format secscan reads
+type terraformState struct {
+	Version          int    `json:"version"`
+	TerraformVersion string `json:"terraform_version"`
+	Serial           int    `json:"serial"`
+	Lineage          string `json:"lineage"`
+	Outputs          map[string]struct {
+		Value     interface{} `json:"value"`
+		Sensitive bool        `json:"sensitive"`
+	} `json:"outputs"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey            interface{}       `json:"index_key"`
+			Attributes          interface{}       `json:"attributes"`
+			SensitiveAttributes []json.RawMessage `json:"sensitive_attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// terraformAddress builds a resource instance address such as
+// module.db.aws_db_instance.main["primary"]
+func terraformAddress(module, mode, typ, name string, indexKey interface{}) string {
+	addr := typ + "." + name
+	if mode == "data" {
+		addr = "data." + addr
+	}
+	if module != "" {
+		addr = module + "." + addr
+	}
+	switch k := indexKey.(type) {
+	case float64:
+		addr += fmt.Sprintf("[%d]", int(k))
+	case string:
+		addr += fmt.Sprintf("[%q]", k)
+	}
+	return addr
+}
+
+// terraformSensitivePaths converts sensitive_attributes entries (lists of
+// get_attr / index steps) into attribute paths like kube_config[0].client_key
+func terraformSensitivePaths(raw []json.RawMessage) []string {
+	type step struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+	var out []string
+	for _, r := range raw {
+		var steps []step
+		if err := json.Unmarshal(r, &steps); err != nil {
+			continue
+		}
+		path := ""
+		for _, s := range steps {
+			switch s.Type {
+			case "get_attr":
+				var name string
+				if json.Unmarshal(s.Value, &name) == nil {
+					path = joinKeyPath(path, name)
+				}
+			case "index":
+				var key struct {
+					Value interface{} `json:"value"`
+				}
+				if json.Unmarshal(s.Value, &key) != nil {
+					continue
+				}
+				switch v := key.Value.(type) {
+				case float64:
+					path += fmt.Sprintf("[%d]", int(v))
+				case string:
+					path = joinKeyPath(path, v)
+				}
+			}
+		}
+		if path != "" {
+			out = append(out, path)
+		}
+	}
+	return out
+}
+
+// underPath reports whether an attribute path equals or is nested in prefix
+func underPath(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[")
+}
+
+// scanTerraformState reports the committed state file itself and proposes
+// sensitive resource attributes and outputs as candidates. Line findings on
+// those values are annotated with the resource address.
+func scanTerraformState(path string, data []byte, findings []Finding, config *Config) ([]structuredCandidate, []Finding) {
+	var state terraformState
+	if err := json.Unmarshal(data, &state); err != nil || state.Version == 0 && state.Lineage == "" {
+		return nil, findings
+	}
+
+	findings = append(findings, Finding{
+		File:       path,
+		Line:       1,
+		Pattern:    terraformStateRuleName,
+		Severity:   config.detectorSeverity(terraformStateRuleName),
+		Excerpt:    fmt.Sprintf("Terraform state (serial %d, %d resources)", state.Serial, len(state.Resources)),
+		RawValue:   state.Lineage,
+		Confidence: 1.0,
+		Verified:   false,
+		Metadata: map[string]string{
+			"format":            formatTerraformState,
+			"lineage":           state.Lineage,
+			"terraform_version": state.TerraformVersion,
+		},
+		Hash: generateHash(path, terraformStateRuleName, state.Lineage),
+	})
+
+	// Line numbers come from the flattened document, keyed by JSON path
+	pairs, _ := parseJSONKeyValues(data)
+	lines := make(map[string]int, len(pairs))
+	for _, kv := range pairs {
+		lines[kv.Path] = kv.Line
+	}
+
+	var candidates []structuredCandidate
+	add := func(jsonPath, address, attribute, value string) {
+		line, ok := lines[jsonPath]
+		if !ok {
+			return
+		}
+		c := structuredCandidate{
+			keyValue:  keyValue{Path: jsonPath, Value: value, Line: line},
+			Pattern:   terraformSecretRuleName,
+			Metadata:  map[string]string{"resource_address": address},
+			Label:     address,
+			Multiline: true,
+		}
+		if attribute != "" {
+			c.Metadata["attribute"] = attribute
+			c.Label = address + "." + attribute
+		}
+		candidates = append(candidates, c)
+	}
+
+	for ri, r := range state.Resources {
+		for ii, inst := range r.Instances {
+			address := terraformAddress(r.Module, r.Mode, r.Type, r.Name, inst.IndexKey)
+			sensitive := terraformSensitivePaths(inst.SensitiveAttributes)
+			prefix := fmt.Sprintf("resources[%d].instances[%d].attributes", ri, ii)
+
+			var attrs []keyValue
+			flattenJSONValue(inst.Attributes, prefix, &attrs)
+			for _, a := range attrs {
+				attribute := strings.TrimPrefix(a.Path, prefix+".")
+				marked := false
+				for _, p := range sensitive {
+					if underPath(attribute, p) {
+						marked = true
+						break
+					}
+				}
+				name := attribute[strings.LastIndexByte(attribute, '.')+1:]
+				// random_password results predate sensitive_attributes in older states
+				if marked || terraformSensitiveAttributes[name] || isSecretKey(attribute) ||
+					r.Type == "random_password" && attribute == "result" {
+					add(a.Path, address, attribute, a.Value)
+				}
+			}
+		}
+	}
+
+	for name, out := range state.Outputs {
+		if !out.Sensitive && !isSecretKey(name) {
+			continue
+		}
+		prefix := "outputs." + name + ".value"
+		var values []keyValue
+		flattenJSONValue(out.Value, prefix, &values)
+		for _, v := range values {
+			add(v.Path, "output."+name, strings.TrimPrefix(strings.TrimPrefix(v.Path, prefix), "."), v.Value)
+		}
+	}
+
+	// Rule findings on the same lines get the resource context too
+	byLine := make(map[int]map[string]string)
+	for _, c := range candidates {
+		byLine[c.Line] = c.Metadata
+	}
+	for i := range findings {
+		m, ok := byLine[findings[i].Line]
+		if !ok || findings[i].Pattern == terraformStateRuleName {
+			continue
+		}
+		if findings[i].Metadata == nil {
+			findings[i].Metadata = make(map[string]string)
+		}
+		for k, v := range m {
+			findings[i].Metadata[k] = v
+		}
+	}
+	return candidates, findings
+}
+
+// flattenJSONValue collects the string leaves of a decoded JSON value
+func flattenJSONValue(v interface{}, path string, out *[]keyValue) {
+	switch t := v.(type) {
+	case string:
+		*out = append(*out, keyValue{Path: path, Value: t})
+	case map[string]interface{}:
+		for k, child := range t {
+			flattenJSONValue(child, joinKeyPath(path, k), out)
+		}
+	case []interface{}:
+		for i, child := range t {
+			flattenJSONValue(child, fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	}
+}
+
+var (
+	hclInlinePair   = regexp.MustCompile(`([A-Za-z_][\w\-]*|"[^"]*")\s*[=:]\s*("(?:[^"\\]|\\.)*")`)
+	hclQuotedString = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+)
+
+// hclUnquote returns the value of a quoted HCL string
+func hclUnquote(s string) string {
+	if v, err := strconv.Unquote(s); err == nil {
+		return v
+	}
+	return strings.Trim(s, `"`)
+}
+
+// stripHCLComment removes # and // comments outside of quoted strings
+func stripHCLComment(line string) string {
+	inQuote := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && inQuote:
+			i++
+		case c == '"':
+			inQuote = !inQuote
+		case !inQuote && (c == '#' || c == '/' && i+1 < len(line) && line[i+1] == '/'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseTFVarsKeyValues parses the string assignments of a .tfvars file,
+// including nested maps, lists and heredocs
+func parseTFVarsKeyValues(data []byte) []keyValue {
+	type scope struct {
+		path string
+		list bool
+		n    int
+	}
+	var out []keyValue
+	var stack []*scope
+	current := func() *scope {
+		if len(stack) == 0 {
+			return &scope{}
+		}
+		return stack[len(stack)-1]
+	}
+
+	lines := strings.Split(string(data), "\n")
+	inComment := false
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(lines[i])
+		if inComment {
+			if strings.Contains(line, "*/") {
+				inComment = false
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "/*") {
+			inComment = !strings.Contains(line, "*/")
+			continue
+		}
+		line = strings.TrimSpace(stripHCLComment(line))
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '}' || line[0] == ']' {
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		parent := current()
+		if parent.list {
+			switch {
+			case line[0] == '{':
+				stack = append(stack, &scope{path: fmt.Sprintf("%s[%d]", parent.path, parent.n)})
+				parent.n++
+			case line[0] == '"':
+				for _, q := range hclQuotedString.FindAllString(line, -1) {
+					out = append(out, keyValue{Path: fmt.Sprintf("%s[%d]", parent.path, parent.n), Value: hclUnquote(q), Line: lineNo})
+					parent.n++
+				}
+			}
+			continue
+		}
+
+		eq := strings.IndexAny(line, "=:")
+		if eq <= 0 {
+			continue
+		}
+		key := strings.Trim(strings.TrimSpace(line[:eq]), `"`)
+		path := joinKeyPath(parent.path, key)
+		rest := strings.TrimSpace(line[eq+1:])
+
+		switch {
+		case rest == "{":
+			stack = append(stack, &scope{path: path})
+		case rest == "[":
+			stack = append(stack, &scope{path: path, list: true})
+		case strings.HasPrefix(rest, "{"):
+			for _, m := range hclInlinePair.FindAllStringSubmatch(rest, -1) {
+				out = append(out, keyValue{Path: joinKeyPath(path, strings.Trim(m[1], `"`)), Value: hclUnquote(m[2]), Line: lineNo})
+			}
+		case strings.HasPrefix(rest, "["):
+			for n, q := range hclQuotedString.FindAllString(rest, -1) {
+				out = append(out, keyValue{Path: fmt.Sprintf("%s[%d]", path, n), Value: hclUnquote(q), Line: lineNo})
+			}
+		case strings.HasPrefix(rest, "<<"):
+			indented := strings.HasPrefix(rest, "<<-")
+			marker := strings.TrimPrefix(strings.TrimPrefix(rest, "<<"), "-")
+			var body []string
+			for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != marker {
+				i++
+				if indented {
+					body = append(body, strings.TrimLeft(lines[i], " \t"))
+				} else {
+					body = append(body, lines[i])
+				}
+			}
+			i++ // closing marker
+			out = append(out, keyValue{Path: path, Value: strings.Join(body, "\n"), Line: lineNo})
+		case strings.HasPrefix(rest, `"`):
+			if q := hclQuotedString.FindString(rest); q != "" {
+				out = append(out, keyValue{Path: path, Value: hclUnquote(q), Line: lineNo})
+			}
+		}
+	}
+	return out
+}
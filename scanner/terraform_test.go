@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestScanTerraformState verifies the state-file finding and resource addresses
+func TestScanTerraformState(t *testing.T) {
+	data, err := os.ReadFile("testdata/terraform.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err := scanReaderForSecrets(context.Background(), SourceStdin, "terraform.tfstate", strings.NewReader(string(data)), nil, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byAddress := make(map[string]Finding)
+	var state *Finding
+	for i, f := range findings {
+		switch f.Pattern {
+		case terraformStateRuleName:
+			state = &findings[i]
+		case terraformSecretRuleName:
+			byAddress[f.Metadata["resource_address"]+"."+f.Metadata["attribute"]] = f
+		}
+	}
+
+	if state == nil || state.Line != 1 || state.Metadata["lineage"] != "3f1b6c2e-8a4d-4f0e-9b7a-2d5c1e0f9a11" {
+		t.Errorf("missing or unexpected state-file finding: %+v", state)
+	}
+	if len(byAddress) != 3 {
+		t.Errorf("got %d sensitive values, want 3: %v", len(byAddress), byAddress)
+	}
+
+	password, ok := byAddress[`module.db.aws_db_instance.main["primary"].password`]
+	if !ok {
+		t.Fatal("missing finding for the sensitive password attribute")
+	}
+	if password.RawValue != "Zr8pQ1xW3vB6" || password.Line != 31 {
+		t.Errorf("unexpected password finding: %+v", password)
+	}
+	if _, ok := byAddress["tls_private_key.deploy.private_key_openssh"]; !ok {
+		t.Error("missing finding for the unmarked private key attribute")
+	}
+	if _, ok := byAddress["output.admin_token."]; !ok {
+		t.Error("missing finding for the sensitive output")
+	}
+}
+
+// TestParseTFVars verifies assignments, nested maps, lists and heredocs
+func TestParseTFVars(t *testing.T) {
+	input := `# comment
+region      = "eu-west-1"
+db_password = "tfvarsPw9876" // inline
+tags = {
+  owner = "ops"
+}
+inline = { token = "abc", "other" = "def" }
+zones = [
+  "a",
+  "b",
+]
+cert = <<-EOT
+  line one
+  line two
+EOT
+`
+	got := make(map[string]keyValue)
+	for _, kv := range parseTFVarsKeyValues([]byte(input)) {
+		got[kv.Path] = kv
+	}
+
+	want := map[string]struct {
+		value string
+		line  int
+	}{
+		"region":       {"eu-west-1", 2},
+		"db_password":  {"tfvarsPw9876", 3},
+		"tags.owner":   {"ops", 5},
+		"inline.token": {"abc", 7},
+		"inline.other": {"def", 7},
+		"zones[1]":     {"b", 10},
+		"cert":         {"line one\nline two", 12},
+	}
+	for path, w := range want {
+		kv, ok := got[path]
+		if !ok {
+			t.Errorf("missing %s", path)
+			continue
+		}
+		if kv.Value != w.value || kv.Line != w.line {
+			t.Errorf("%s = %q at line %d, want %q at line %d", path, kv.Value, kv.Line, w.value, w.line)
+		}
+	}
+}
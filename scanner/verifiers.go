@@ -0,0 +1,172 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Default provider API endpoints, by verifier name
+var defaultVerifyBaseURLs = map[string]string{
+	"aws":      "https://sts.amazonaws.com",
+	"github":   "https://api.github.com",
+	"slack":    "https://slack.com",
+	"stripe":   "https://api.stripe.com",
+	"sendgrid": "https://api.sendgrid.com",
+}
+
+// DefaultVerifiers returns the built-in verifiers by rule name. baseURLs
+// replaces provider endpoints by verifier name (aws, github, slack, stripe,
+// sendgrid), for GitHub Enterprise or a local stand-in server.
+func DefaultVerifiers(baseURLs map[string]string) map[string]Verifier {
+	url := func(name string) string {
+		if u, ok := baseURLs[name]; ok && u != "" {
+			return strings.TrimRight(u, "/")
+		}
+		return defaultVerifyBaseURLs[name]
+	}
+	github := &GitHubVerifier{BaseURL: url("github")}
+	stripe := &StripeVerifier{BaseURL: url("stripe")}
+	return map[string]Verifier{
+		"aws_access_key":    &AWSVerifier{BaseURL: url("aws"), Region: "us-east-1"},
+		"github_pat":        github,
+		"github_oauth":      github,
+		"github_app":        github,
+		"slack_token":       &SlackVerifier{BaseURL: url("slack")},
+		"stripe_sk":         stripe,
+		"stripe_restricted": stripe,
+		"sendgrid_api":      &SendGridVerifier{BaseURL: url("sendgrid")},
+	}
+}
+
+// GitHubVerifier calls GET /user with a GitHub token
+type GitHubVerifier struct {
+	BaseURL string
+}
+
+func (v *GitHubVerifier) Name() string      { return "github" }
+func (v *GitHubVerifier) Related() []string { return nil }
+
+func (v *GitHubVerifier) Verify(ctx context.Context, client *http.Client, s Secret) Verification {
+	status, body, err := verifyRequest(ctx, client, http.MethodGet, v.BaseURL+"/user", nil, http.Header{
+		"Authorization": {"token " + s.Value},
+		"Accept":        {"application/vnd.github+json"},
+	})
+	switch {
+	case err != nil:
+		return unknownVerification(0, err)
+	case status == http.StatusOK:
+		var user struct {
+			Login string `json:"login"`
+		}
+		_ = json.Unmarshal(body, &user)
+		return Verification{Status: VerificationVerified, Reason: "authenticated as " + user.Login}
+	case status == http.StatusUnauthorized:
+		return Verification{Status: VerificationInvalid, Reason: "bad credentials"}
+	}
+	return unknownVerification(status, nil)
+}
+
+// SlackVerifier calls auth.test with a Slack token
+type SlackVerifier struct {
+	BaseURL string
+}
+
+func (v *SlackVerifier) Name() string      { return "slack" }
+func (v *SlackVerifier) Related() []string { return nil }
+
+// slackInvalidErrors are auth.test errors meaning the token is not live
+var slackInvalidErrors = map[string]bool{
+	"invalid_auth":     true,
+	"not_authed":       true,
+	"account_inactive": true,
+	"token_revoked":    true,
+	"token_expired":    true,
+}
+
+func (v *SlackVerifier) Verify(ctx context.Context, client *http.Client, s Secret) Verification {
+	status, body, err := verifyRequest(ctx, client, http.MethodPost, v.BaseURL+"/api/auth.test", nil, http.Header{
+		"Authorization": {"Bearer " + s.Value},
+	})
+	if err != nil || status != http.StatusOK {
+		return unknownVerification(status, err)
+	}
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		Team  string `json:"team"`
+		User  string `json:"user"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return unknownVerification(status, err)
+	}
+	switch {
+	case resp.OK:
+		return Verification{Status: VerificationVerified, Reason: fmt.Sprintf("user %s in team %s", resp.User, resp.Team)}
+	case slackInvalidErrors[resp.Error]:
+		return Verification{Status: VerificationInvalid, Reason: resp.Error}
+	}
+	return Verification{Status: VerificationUnknown, Reason: resp.Error}
+}
+
+// StripeVerifier calls GET /v1/balance with a Stripe secret or restricted key
+type StripeVerifier struct {
+	BaseURL string
+}
+
+func (v *StripeVerifier) Name() string      { return "stripe" }
+func (v *StripeVerifier) Related() []string { return nil }
+
+func (v *StripeVerifier) Verify(ctx context.Context, client *http.Client, s Secret) Verification {
+	status, _, err := verifyRequest(ctx, client, http.MethodGet, v.BaseURL+"/v1/balance", nil, http.Header{
+		"Authorization": {"Bearer " + s.Value},
+	})
+	switch {
+	case err != nil:
+		return unknownVerification(0, err)
+	case status == http.StatusOK:
+		return Verification{Status: VerificationVerified, Reason: "can read the account balance"}
+	case status == http.StatusForbidden:
+		// restricted keys without balance access are still live
+		return Verification{Status: VerificationVerified, Reason: "valid key without balance access"}
+	case status == http.StatusUnauthorized:
+		return Verification{Status: VerificationInvalid, Reason: "invalid API key"}
+	}
+	return unknownVerification(status, nil)
+}
+
+// SendGridVerifier calls GET /v3/scopes with a SendGrid API key
+type SendGridVerifier struct {
+	BaseURL string
+}
+
+func (v *SendGridVerifier) Name() string      { return "sendgrid" }
+func (v *SendGridVerifier) Related() []string { return nil }
+
+// maxReportedScopes caps how many scopes are listed in a verification reason
+const maxReportedScopes = 5
+
+func (v *SendGridVerifier) Verify(ctx context.Context, client *http.Client, s Secret) Verification {
+	status, body, err := verifyRequest(ctx, client, http.MethodGet, v.BaseURL+"/v3/scopes", nil, http.Header{
+		"Authorization": {"Bearer " + s.Value},
+	})
+	switch {
+	case err != nil:
+		return unknownVerification(0, err)
+	case status == http.StatusOK:
+		var resp struct {
+			Scopes []string `json:"scopes"`
+		}
+		_ = json.Unmarshal(body, &resp)
+		scopes := resp.Scopes
+		if len(scopes) > maxReportedScopes {
+			scopes = append(scopes[:maxReportedScopes:maxReportedScopes], "...")
+		}
+		return Verification{Status: VerificationVerified, Reason: fmt.Sprintf("%d scopes: %s", len(resp.Scopes), strings.Join(scopes, ", "))}
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return Verification{Status: VerificationInvalid, Reason: "invalid API key"}
+	}
+	return unknownVerification(status, nil)
+}
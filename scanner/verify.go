@@ -0,0 +1,236 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcomes of live verification
+const (
+	VerificationVerified = "verified" // the provider accepted the secret
+	VerificationInvalid  = "invalid"  // the provider rejected it, or it is malformed
+	VerificationUnknown  = "unknown"  // the provider could not be asked or gave no answer
+)
+
+// Defaults for VerifyOptions
+const (
+	DefaultVerifyTimeout = 10 * time.Second
+	DefaultVerifyRate    = 2.0 // requests per second per provider
+)
+
+// Verification is the outcome of checking a finding with its provider
+type Verification struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Secret is a credential handed to a Verifier
+type Secret struct {
+	Rule  string
+	Value string
+	// Related holds the values of other findings in the same file or commit,
+	// by rule, for the rules the verifier lists in Related
+	Related map[string][]string
+}
+
+// Verifier checks secrets against a provider's API
+type Verifier interface {
+	// Name identifies the provider; requests are rate limited per name
+	Name() string
+	// Related lists rules whose values in the same file or commit are needed
+	// to verify a secret, such as the AWS secret key for an access key ID
+	Related() []string
+	// Verify asks the provider whether secret is live
+	Verify(ctx context.Context, client *http.Client, secret Secret) Verification
+}
+
+// VerifyOptions configures VerifyFindings
+type VerifyOptions struct {
+	// Verifiers by rule name; DefaultVerifiers(nil) when nil
+	Verifiers map[string]Verifier
+	// Client makes the requests; one with Timeout is created when nil
+	Client *http.Client
+	// Timeout bounds each request; DefaultVerifyTimeout when 0
+	Timeout time.Duration
+	// Rate is the most requests per second sent to one provider;
+	// DefaultVerifyRate when 0
+	Rate float64
+}
+
+// VerifyFindings checks each finding that has a verifier for its rule and
+// records the outcome in Verification and Verified. Each distinct secret is
+// checked once. Tokens that failed offline checksum validation are reported
+// invalid without a request. When ctx is done, the remaining findings are
+// left unknown.
+func VerifyFindings(ctx context.Context, findings []Finding, opts VerifyOptions) []Finding {
+	verifiers := opts.Verifiers
+	if verifiers == nil {
+		verifiers = DefaultVerifiers(nil)
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultVerifyTimeout
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
+	rate := opts.Rate
+	if rate <= 0 {
+		rate = DefaultVerifyRate
+	}
+
+	// Values of every finding by location, for verifiers that need related parts
+	related := make(map[string]map[string][]string)
+	for _, f := range findings {
+		loc := verifyLocation(f)
+		if related[loc] == nil {
+			related[loc] = make(map[string][]string)
+		}
+		related[loc][f.Pattern] = append(related[loc][f.Pattern], f.RawValue)
+	}
+
+	cache := make(map[string]Verification)
+	limiters := make(map[string]*rateLimiter)
+	out := make([]Finding, len(findings))
+	for i, f := range findings {
+		out[i] = f
+		v, ok := verifiers[f.Pattern]
+		if !ok || f.RawValue == "" {
+			continue
+		}
+
+		var result Verification
+		if f.Metadata["checksum_valid"] == "false" {
+			result = Verification{Status: VerificationInvalid, Reason: "checksum does not match"}
+		} else {
+			secret := Secret{Rule: f.Pattern, Value: f.RawValue, Related: make(map[string][]string)}
+			for _, rule := range v.Related() {
+				secret.Related[rule] = related[verifyLocation(f)][rule]
+			}
+			key := secretFingerprint(v.Name(), secret)
+			if cached, ok := cache[key]; ok {
+				result = cached
+			} else {
+				limiter := limiters[v.Name()]
+				if limiter == nil {
+					limiter = newRateLimiter(rate)
+					limiters[v.Name()] = limiter
+				}
+				if err := limiter.wait(ctx); err != nil {
+					result = Verification{Status: VerificationUnknown, Reason: err.Error()}
+				} else {
+					reqCtx, cancel := context.WithTimeout(ctx, timeout)
+					result = v.Verify(reqCtx, client, secret)
+					cancel()
+					cache[key] = result
+				}
+			}
+		}
+		out[i].Verification = &result
+		out[i].Verified = result.Status == VerificationVerified
+	}
+	return out
+}
+
+// verifyLocation groups findings that are in the same file or commit
+func verifyLocation(f Finding) string {
+	if f.Commit != "" {
+		return f.Commit + "\x00" + f.Metadata["path"]
+	}
+	return f.File
+}
+
+// secretFingerprint identifies a secret and its related parts without keeping
+// the values themselves
+func secretFingerprint(provider string, s Secret) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", provider, s.Rule, s.Value)
+	rules := make([]string, 0, len(s.Related))
+	for rule := range s.Related {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for _, rule := range rules {
+		values := append([]string(nil), s.Related[rule]...)
+		sort.Strings(values)
+		fmt.Fprintf(h, "\x00%s=%s", rule, strings.Join(values, "\x00"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// rateLimiter spaces out requests to one provider
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next request may be sent or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// maxVerifyResponse caps how much of a provider response is read
+const maxVerifyResponse = 1 << 20
+
+// verifyRequest sends a request and returns the response status and body
+func verifyRequest(ctx context.Context, client *http.Client, method, url string, body io.Reader, header http.Header) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", "secscan")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxVerifyResponse))
+	return resp.StatusCode, data, err
+}
+
+// unknownVerification reports a request that failed or got an unexpected answer
+func unknownVerification(status int, err error) Verification {
+	if err != nil {
+		return Verification{Status: VerificationUnknown, Reason: err.Error()}
+	}
+	return Verification{Status: VerificationUnknown, Reason: fmt.Sprintf("unexpected status %d", status)}
+}
@@ -0,0 +1,162 @@
+package scanner
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSVerifier calls STS GetCallerIdentity with an access key ID and a secret
+// access key found in the same file or commit
+type AWSVerifier struct {
+	BaseURL string
+	Region  string
+}
+
+func (v *AWSVerifier) Name() string      { return "aws" }
+func (v *AWSVerifier) Related() []string { return []string{"aws_secret_key"} }
+
+// awsSecretValuePattern extracts the key from an aws_secret_key match
+var awsSecretValuePattern = regexp.MustCompile(`[0-9a-zA-Z/+]{40}`)
+
+// getCallerIdentityBody is the form body of an STS GetCallerIdentity call
+const getCallerIdentityBody = "Action=GetCallerIdentity&Version=2011-06-15"
+
+func (v *AWSVerifier) Verify(ctx context.Context, client *http.Client, s Secret) Verification {
+	var secrets []string
+	for _, m := range s.Related["aws_secret_key"] {
+		if key := awsSecretValuePattern.FindString(m); key != "" {
+			secrets = append(secrets, key)
+		}
+	}
+	if len(secrets) == 0 {
+		return Verification{Status: VerificationUnknown, Reason: "no secret access key found with the access key ID"}
+	}
+
+	for _, secret := range secrets {
+		status, body, err := v.getCallerIdentity(ctx, client, s.Value, secret)
+		if err != nil {
+			return unknownVerification(0, err)
+		}
+		switch status {
+		case http.StatusOK:
+			var resp struct {
+				Arn string `xml:"GetCallerIdentityResult>Arn"`
+			}
+			_ = xml.Unmarshal(body, &resp)
+			return Verification{Status: VerificationVerified, Reason: resp.Arn}
+		case http.StatusForbidden, http.StatusUnauthorized:
+			var resp struct {
+				Code string `xml:"Error>Code"`
+			}
+			_ = xml.Unmarshal(body, &resp)
+			if resp.Code == "InvalidClientTokenId" {
+				return Verification{Status: VerificationInvalid, Reason: "access key ID does not exist"}
+			}
+			// SignatureDoesNotMatch: try the next secret key
+		default:
+			return unknownVerification(status, nil)
+		}
+	}
+	return Verification{Status: VerificationUnknown, Reason: "access key ID exists but no secret access key found matches it"}
+}
+
+// getCallerIdentity sends a signed GetCallerIdentity request
+func (v *AWSVerifier) getCallerIdentity(ctx context.Context, client *http.Client, accessKey, secretKey string) (int, []byte, error) {
+	endpoint, err := url.Parse(v.BaseURL + "/")
+	if err != nil {
+		return 0, nil, err
+	}
+	header := http.Header{
+		"Content-Type": {"application/x-www-form-urlencoded; charset=utf-8"},
+	}
+	signAWSv4(http.MethodPost, endpoint, header, []byte(getCallerIdentityBody), accessKey, secretKey, v.Region, "sts", time.Now())
+	return verifyRequest(ctx, client, http.MethodPost, endpoint.String(), strings.NewReader(getCallerIdentityBody), header)
+}
+
+// signAWSv4 adds the X-Amz-Date and Authorization headers of an AWS Signature
+// Version 4 signature to header. The Host header and every header already
+// set are signed.
+func signAWSv4(method string, u *url.URL, header http.Header, body []byte, accessKey, secretKey, region, service string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	header.Set("X-Amz-Date", amzDate)
+
+	// Canonical headers: lower-case names, sorted, with trimmed values
+	signed := map[string]string{"host": u.Host}
+	for k, v := range header {
+		signed[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(signed))
+	for k := range signed {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + signed[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			params = append(params, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsEscape percent-encodes everything but unreserved characters, as SigV4
+// requires
+func awsEscape(s string) string {
+	return strings.NewReplacer("+", "%20", "%7E", "~").Replace(url.QueryEscape(s))
+}
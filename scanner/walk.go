@@ -0,0 +1,342 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GitignorePattern represents a pattern from .gitignore with its base directory
+type GitignorePattern struct {
+	Pattern   string
+	Negation  bool
+	Directory bool
+	BaseDir   string
+}
+
+// Enhanced skip directories with more comprehensive list
+var defaultSkipDirs = []string{
+	"node_modules", ".git", "dist", "build", ".next", "venv", "target",
+	"__pycache__", ".venv", "env", ".env", "vendor", "coverage",
+	".pytest_cache", ".mypy_cache", ".tox", "bin", "obj", ".gradle",
+	".idea", ".vscode", ".terraform", "*.egg-info", ".nuxt",
+}
+
+// File extensions that should be skipped
+var defaultSkipExtensions = []string{
+	".jpg", ".jpeg", ".png", ".gif", ".ico", ".svg", ".webp",
+	".mp4", ".avi", ".mov", ".mp3", ".wav", ".pdf", ".zip",
+	".tar", ".gz", ".bz2", ".7z", ".rar", ".exe", ".dll",
+	".so", ".dylib", ".bin", ".db", ".sqlite", ".lock",
+	".min.js", ".min.css", ".map", ".woff", ".woff2", ".ttf", ".eot",
+}
+
+func shouldSkipDir(d string) bool {
+	base := filepath.Base(d)
+	for _, s := range defaultSkipDirs {
+		if base == s || strings.HasPrefix(base, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func shouldSkipFile(name string) bool {
+	base := filepath.Base(name)
+
+	// Skip hidden files except specific configs
+	if strings.HasPrefix(base, ".") && !isEnvFile(base) {
+		return true
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, skipExt := range defaultSkipExtensions {
+		if ext == skipExt {
+			return true
+		}
+	}
+
+	// Skip all types of lock files comprehensively
+	lockFilePatterns := []string{
+		".lock",             // Gemfile.lock, Pipfile.lock, etc.
+		"-lock.json",        // package-lock.json
+		"-lock.yaml",        // pnpm-lock.yaml
+		"lock.json",         // composer.lock.json
+		"lock.yaml",         // yarn.lock (though it's typically yarn.lock)
+		"yarn.lock",         // yarn lock file
+		"pnpm-lock.yaml",    // pnpm lock file
+		"package-lock.json", // npm lock file
+		"composer.lock",     // PHP composer lock
+		"Gemfile.lock",      // Ruby Gemfile lock
+		"Pipfile.lock",      // Python Pipfile lock
+		"poetry.lock",       // Python poetry lock
+		"Cargo.lock",        // Rust cargo lock
+		"mix.lock",          // Elixir mix lock
+		"pubspec.lock",      // Dart/Flutter pubspec lock
+		"go.sum",            // Go dependencies checksum
+		"Podfile.lock",      // iOS CocoaPods lock
+		"Cartfile.resolved", // iOS Carthage lock
+	}
+
+	for _, pattern := range lockFilePatterns {
+		if strings.HasSuffix(base, pattern) || base == pattern {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseGitignoreLine parses a single line from .gitignore
+func parseGitignoreLine(line, baseDir string) *GitignorePattern {
+	line = strings.TrimSpace(line)
+
+	// Skip empty lines and comments
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	pattern := GitignorePattern{
+		BaseDir: baseDir,
+	}
+
+	// Check for negation
+	if strings.HasPrefix(line, "!") {
+		pattern.Negation = true
+		line = strings.TrimPrefix(line, "!")
+	}
+
+	// Check if pattern is for directories only
+	if strings.HasSuffix(line, "/") {
+		pattern.Directory = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	pattern.Pattern = line
+	return &pattern
+}
+
+// loadGitignore loads patterns from a .gitignore file
+func loadGitignore(path string) ([]GitignorePattern, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	baseDir := filepath.Dir(path)
+	var patterns []GitignorePattern
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if pattern := parseGitignoreLine(scanner.Text(), baseDir); pattern != nil {
+			patterns = append(patterns, *pattern)
+		}
+	}
+
+	return patterns, scanner.Err()
+}
+
+// CollectGitignorePatterns finds and loads all .gitignore files in the repository
+func CollectGitignorePatterns(root string) []GitignorePattern {
+	var allPatterns []GitignorePattern
+
+	// Walk the directory tree to find all .gitignore files
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		// Skip .git directory
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		// Check if this is a .gitignore file
+		if !d.IsDir() && d.Name() == ".gitignore" {
+			patterns, err := loadGitignore(path)
+			if err == nil {
+				allPatterns = append(allPatterns, patterns...)
+			}
+		}
+
+		return nil
+	})
+
+	return allPatterns
+}
+
+// matchGitignorePattern checks if a path matches a gitignore pattern
+func matchGitignorePattern(path string, pattern GitignorePattern) bool {
+	// Convert absolute path to relative path from pattern's base directory
+	relPath, err := filepath.Rel(pattern.BaseDir, path)
+	if err != nil {
+		return false
+	}
+
+	// If the path is outside the base directory, it doesn't match
+	if strings.HasPrefix(relPath, "..") {
+		return false
+	}
+
+	patternStr := pattern.Pattern
+
+	// Handle different pattern types
+	if strings.HasPrefix(patternStr, "/") {
+		// Anchored to base directory
+		patternStr = strings.TrimPrefix(patternStr, "/")
+		return matchPattern(patternStr, relPath)
+	} else if strings.Contains(patternStr, "/") {
+		// Contains slash - match anywhere in path
+		return matchPattern(patternStr, relPath)
+	} else {
+		// No slash - match basename anywhere in tree
+		parts := strings.Split(relPath, string(filepath.Separator))
+		for _, part := range parts {
+			if matchPattern(patternStr, part) {
+				return true
+			}
+		}
+		// Also try full relative path
+		return matchPattern(patternStr, relPath)
+	}
+}
+
+// matchPattern performs glob-style pattern matching
+func matchPattern(pattern, name string) bool {
+	// Handle ** for matching any number of directories
+	if strings.Contains(pattern, "**") {
+		parts := strings.Split(pattern, "**")
+		if len(parts) == 2 {
+			prefix := strings.TrimSuffix(parts[0], "/")
+			suffix := strings.TrimPrefix(parts[1], "/")
+
+			if prefix != "" && !strings.HasPrefix(name, prefix) {
+				return false
+			}
+			if suffix != "" && !strings.HasSuffix(name, suffix) {
+				return false
+			}
+			return true
+		}
+	}
+
+	// Simple glob matching
+	matched, _ := filepath.Match(pattern, name)
+	if matched {
+		return true
+	}
+
+	// Try matching with the full path for patterns with directory separators
+	matched, _ = filepath.Match(pattern, filepath.Base(name))
+	return matched
+}
+
+// isGitignored checks if a path should be ignored based on gitignore patterns
+func isGitignored(path string, patterns []GitignorePattern, isDir bool) bool {
+	ignored := false
+
+	// Process patterns in order (later patterns override earlier ones)
+	for _, pattern := range patterns {
+		// Skip directory-only patterns for files
+		if pattern.Directory && !isDir {
+			continue
+		}
+
+		if matchGitignorePattern(path, pattern) {
+			if pattern.Negation {
+				ignored = false // Negation pattern - don't ignore
+			} else {
+				ignored = true // Normal pattern - ignore
+			}
+		}
+	}
+
+	return ignored
+}
+
+func looksLikeTextFile(name string) bool {
+	// Skip if should skip file
+	if shouldSkipFile(name) {
+		return false
+	}
+
+	if structuredFormat(name) != "" || isNotebookFile(name) {
+		return true
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	switch ext {
+	case ".go", ".js", ".ts", ".tsx", ".jsx", ".java", ".py", ".rb", ".php",
+		".json", ".yaml", ".yml", ".env", ".cfg", ".toml", ".md", ".txt",
+		".sh", ".bash", ".zsh", ".ps1", ".sql", ".xml", ".html", ".css",
+		".c", ".cpp", ".h", ".hpp", ".cs", ".rs", ".kt", ".swift", ".scala",
+		".clj", ".ex", ".exs", ".erl", ".hrl", ".vim", ".lua", ".pl", ".r",
+		".Dockerfile", ".tf", ".hcl", ".proto", ".graphql", ".vue", ".svelte",
+		".pem", ".key", ".ppk", ".pub", ".crt", ".cer":
+		return true
+	default:
+		// Check if file has no extension (might be script)
+		if ext == "" {
+			return true
+		}
+		return false
+	}
+}
+
+// walkFiles calls action for each text file under root. The walk stops with
+// ctx's error when ctx is done.
+func walkFiles(ctx context.Context, root string, config *Config, stats *Stats, action func(path string) error) error {
+	skipped := func(reason string, dir bool) {
+		if stats != nil {
+			stats.incrementSkipped(reason, dir)
+		}
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			// keep walking for robustness, but record what could not be read
+			if stats != nil {
+				stats.AddError(ScanError{Path: path, Op: OpWalk, Message: err.Error()})
+			}
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			// Check gitignore first if enabled
+			if config.RespectGitignore && isGitignored(path, config.GitignorePatterns, true) {
+				config.logf("Skipping gitignored directory: %s", path)
+				skipped(SkipReasonGitignore, true)
+				return filepath.SkipDir
+			}
+
+			// Then check default skip dirs
+			if shouldSkipDir(path) && path != root {
+				skipped(SkipReasonDirectory, true)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Check gitignore for files if enabled
+		if config.RespectGitignore && isGitignored(path, config.GitignorePatterns, false) {
+			config.logf("Skipping gitignored file: %s", path)
+			skipped(SkipReasonGitignore, false)
+			return nil
+		}
+
+		if !looksLikeTextFile(path) && !(config.ScanArchives && archiveKind(path) != "") {
+			skipped(SkipReasonFileType, false)
+			return nil
+		}
+		return action(path)
+	})
+}
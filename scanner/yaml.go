@@ -0,0 +1,586 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// yamlKind is the kind of a parsed YAML node
+type yamlKind int
+
+const (
+	yamlScalar yamlKind = iota
+	yamlMapping
+	yamlSequence
+)
+
+// yamlNode is a node of a parsed YAML document. The parser covers the block
+// and single-line flow styles used by configuration files and manifests;
+// anchors and tags are dropped and aliases are kept as plain scalars.
+type yamlNode struct {
+	Kind  yamlKind
+	Value string      // scalar value
+	Line  int         // 1-based line where the node's value starts
+	Pairs []yamlPair  // mapping entries in document order
+	Items []*yamlNode // sequence items
+}
+
+// yamlPair is one key of a YAML mapping
+type yamlPair struct {
+	Key   string
+	Line  int
+	Value *yamlNode
+}
+
+// Get returns the value of a mapping key, or nil when n is not a mapping or
+// has no such key
+func (n *yamlNode) Get(key string) *yamlNode {
+	if n == nil || n.Kind != yamlMapping {
+		return nil
+	}
+	for _, p := range n.Pairs {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return nil
+}
+
+// String returns the scalar value of n, or "" for collections and nil nodes
+func (n *yamlNode) String() string {
+	if n == nil || n.Kind != yamlScalar {
+		return ""
+	}
+	return n.Value
+}
+
+// yamlLine is one physical line of a YAML document
+type yamlLine struct {
+	num    int
+	indent int
+	text   string // content after the indentation, right-trimmed
+	raw    string
+}
+
+func (l yamlLine) blank() bool {
+	return l.text == "" || strings.HasPrefix(l.text, "#")
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+var yamlBlockScalarHeader = regexp.MustCompile(`^[|>][-+0-9]*$`)
+
+// parseYAML parses every document in a YAML stream. Empty documents are omitted.
+func parseYAML(data []byte) ([]*yamlNode, error) {
+	var docs []*yamlNode
+	var current []yamlLine
+
+	flush := func() error {
+		p := &yamlParser{lines: current}
+		current = nil
+		p.skip()
+		if p.pos >= len(p.lines) {
+			return nil
+		}
+		node, err := p.parseBlock()
+		if err != nil {
+			return err
+		}
+		p.skip()
+		if p.pos < len(p.lines) {
+			return fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+		}
+		docs = append(docs, node)
+		return nil
+	}
+
+	for i, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		num := i + 1
+
+		if raw == "---" || strings.HasPrefix(raw, "--- ") || raw == "..." {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			if rest := strings.TrimSpace(strings.TrimPrefix(raw, "---")); rest != "" && raw != "..." {
+				current = append(current, yamlLine{num: num, text: rest, raw: rest})
+			}
+			continue
+		}
+		if strings.HasPrefix(raw, "%") && len(current) == 0 {
+			continue // directive
+		}
+
+		text := strings.TrimLeft(raw, " ")
+		current = append(current, yamlLine{
+			num:    num,
+			indent: len(raw) - len(text),
+			text:   strings.TrimRight(text, " \t"),
+			raw:    raw,
+		})
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// skip moves past blank and comment lines
+func (p *yamlParser) skip() {
+	for p.pos < len(p.lines) && p.lines[p.pos].blank() {
+		p.pos++
+	}
+}
+
+// parseBlock parses the block node starting at the current line
+func (p *yamlParser) parseBlock() (*yamlNode, error) {
+	p.skip()
+	l := p.lines[p.pos]
+	if isYAMLSequenceItem(l.text) {
+		return p.parseSequence(l.indent)
+	}
+	if _, _, ok := splitYAMLKey(l.text); ok {
+		return p.parseMapping(l.indent)
+	}
+
+	p.pos++
+	node, err := p.parseInline(stripYAMLProperties(stripYAMLComment(l.text)), l)
+	if err != nil {
+		return nil, err
+	}
+	p.foldPlain(node, l.indent-1)
+	return node, nil
+}
+
+// parseSequence parses "- item" lines at the given indentation
+func (p *yamlParser) parseSequence(indent int) (*yamlNode, error) {
+	node := &yamlNode{Kind: yamlSequence, Line: p.lines[p.pos].num}
+	for {
+		p.skip()
+		if p.pos >= len(p.lines) {
+			break
+		}
+		l := &p.lines[p.pos]
+		if l.indent != indent || !isYAMLSequenceItem(l.text) {
+			break
+		}
+
+		rest := strings.TrimLeft(l.text[1:], " \t")
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			num := l.num
+			p.pos++
+			p.skip()
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				child, err := p.parseBlock()
+				if err != nil {
+					return nil, err
+				}
+				node.Items = append(node.Items, child)
+			} else {
+				node.Items = append(node.Items, &yamlNode{Kind: yamlScalar, Line: num})
+			}
+			continue
+		}
+
+		// Re-read the rest of the line as a block starting at its own column,
+		// so "- key: value" continues on the following, deeper lines
+		l.indent += len(l.text) - len(rest)
+		l.text = rest
+		child, err := p.parseBlock()
+		if err != nil {
+			return nil, err
+		}
+		node.Items = append(node.Items, child)
+	}
+	return node, nil
+}
+
+// parseMapping parses "key: value" lines at the given indentation
+func (p *yamlParser) parseMapping(indent int) (*yamlNode, error) {
+	node := &yamlNode{Kind: yamlMapping, Line: p.lines[p.pos].num}
+	for {
+		p.skip()
+		if p.pos >= len(p.lines) {
+			break
+		}
+		l := p.lines[p.pos]
+		if l.indent != indent {
+			break
+		}
+		key, rest, ok := splitYAMLKey(l.text)
+		if !ok {
+			if isYAMLSequenceItem(l.text) {
+				break
+			}
+			return nil, fmt.Errorf("line %d: expected a mapping key", l.num)
+		}
+		p.pos++
+
+		rest = stripYAMLProperties(stripYAMLComment(rest))
+		var value *yamlNode
+		var err error
+		switch {
+		case rest == "":
+			p.skip()
+			if p.pos < len(p.lines) && (p.lines[p.pos].indent > indent ||
+				p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text)) {
+				value, err = p.parseBlock()
+			} else {
+				value = &yamlNode{Kind: yamlScalar, Line: l.num}
+			}
+		case yamlBlockScalarHeader.MatchString(rest):
+			value = p.parseBlockScalar(rest, indent, l.num)
+		default:
+			value, err = p.parseInline(rest, l)
+			if err == nil {
+				p.foldPlain(value, indent)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.Pairs = append(node.Pairs, yamlPair{Key: key, Line: l.num, Value: value})
+	}
+	return node, nil
+}
+
+// parseInline parses a value written on the same line as its key or dash.
+// Flow collections may continue on the following lines until balanced.
+func (p *yamlParser) parseInline(s string, l yamlLine) (*yamlNode, error) {
+	if strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{") {
+		for !yamlFlowBalanced(s) && p.pos < len(p.lines) {
+			s += " " + stripYAMLComment(p.lines[p.pos].text)
+			p.pos++
+		}
+		fp := &yamlFlowParser{s: s, line: l.num}
+		node, err := fp.parseValue(false)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", l.num, err)
+		}
+		return node, nil
+	}
+	return &yamlNode{Kind: yamlScalar, Value: unquoteYAMLScalar(s), Line: l.num}, nil
+}
+
+// foldPlain joins continuation lines of a multi-line plain scalar
+func (p *yamlParser) foldPlain(node *yamlNode, parentIndent int) {
+	if node.Kind != yamlScalar {
+		return
+	}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.blank() || l.indent <= parentIndent || isYAMLSequenceItem(l.text) {
+			return
+		}
+		if _, _, ok := splitYAMLKey(l.text); ok {
+			return
+		}
+		node.Value += " " + stripYAMLComment(l.text)
+		p.pos++
+	}
+}
+
+// parseBlockScalar reads a literal (|) or folded (>) block scalar
+func (p *yamlParser) parseBlockScalar(header string, parentIndent, headerLine int) *yamlNode {
+	node := &yamlNode{Kind: yamlScalar, Line: headerLine}
+	contentIndent := -1
+	if n, err := strconv.Atoi(strings.Trim(header[1:], "+-")); err == nil && n > 0 {
+		contentIndent = parentIndent + n
+	}
+
+	var parts []string
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if strings.TrimSpace(l.raw) == "" {
+			parts = append(parts, "")
+			p.pos++
+			continue
+		}
+		if l.indent <= parentIndent {
+			break
+		}
+		if contentIndent < 0 {
+			contentIndent = l.indent
+			node.Line = l.num
+		}
+		if len(l.raw) >= contentIndent {
+			parts = append(parts, l.raw[contentIndent:])
+		} else {
+			parts = append(parts, strings.TrimSpace(l.raw))
+		}
+		p.pos++
+	}
+	for len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+
+	if header[0] == '>' {
+		var b strings.Builder
+		for i, part := range parts {
+			if i > 0 {
+				if part == "" || parts[i-1] == "" {
+					b.WriteByte('\n')
+				} else {
+					b.WriteByte(' ')
+				}
+			}
+			b.WriteString(part)
+		}
+		node.Value = b.String()
+	} else {
+		node.Value = strings.Join(parts, "\n")
+	}
+	if !strings.Contains(header, "-") && node.Value != "" {
+		node.Value += "\n"
+	}
+	return node
+}
+
+// isYAMLSequenceItem reports whether a line starts a block sequence entry
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ") || strings.HasPrefix(text, "-\t")
+}
+
+// splitYAMLKey splits "key: rest" into its unquoted key and the remainder
+func splitYAMLKey(text string) (string, string, bool) {
+	if text == "" || strings.ContainsRune("#[{|>%@`&*!?", rune(text[0])) || isYAMLSequenceItem(text) {
+		return "", "", false
+	}
+
+	if text[0] == '"' || text[0] == '\'' {
+		end := yamlQuoteEnd(text)
+		if end < 0 {
+			return "", "", false
+		}
+		rest := strings.TrimLeft(text[end+1:], " \t")
+		if !strings.HasPrefix(rest, ":") || len(rest) > 1 && rest[1] != ' ' && rest[1] != '\t' {
+			return "", "", false
+		}
+		return unquoteYAMLScalar(text[:end+1]), strings.TrimSpace(rest[1:]), true
+	}
+
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '#':
+			if i > 0 && (text[i-1] == ' ' || text[i-1] == '\t') {
+				return "", "", false
+			}
+		case ':':
+			if i+1 == len(text) || text[i+1] == ' ' || text[i+1] == '\t' {
+				key := strings.TrimSpace(text[:i])
+				if key == "" {
+					return "", "", false
+				}
+				return key, strings.TrimSpace(text[i+1:]), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// yamlQuoteEnd returns the index of the quote closing the string at s[0], or -1
+func yamlQuoteEnd(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q:
+			if q == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// stripYAMLComment removes a trailing " # comment" outside of quotes
+func stripYAMLComment(s string) string {
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		if end := yamlQuoteEnd(s); end >= 0 {
+			return strings.TrimSpace(s[:end+1] + stripYAMLComment(s[end+1:]))
+		}
+		return s
+	}
+	if strings.HasPrefix(s, "#") {
+		return ""
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "\t#"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// stripYAMLProperties drops leading anchors (&a) and tags (!t) from a value
+func stripYAMLProperties(s string) string {
+	for strings.HasPrefix(s, "&") || strings.HasPrefix(s, "!") {
+		i := strings.IndexAny(s, " \t")
+		if i < 0 {
+			return ""
+		}
+		s = strings.TrimSpace(s[i:])
+	}
+	return s
+}
+
+// unquoteYAMLScalar returns the value of a plain, single- or double-quoted scalar
+func unquoteYAMLScalar(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return s
+	}
+	switch s[0] {
+	case '"':
+		if end := yamlQuoteEnd(s); end > 0 {
+			if v, err := strconv.Unquote(s[:end+1]); err == nil {
+				return v
+			}
+			return s[1:end]
+		}
+	case '\'':
+		if end := yamlQuoteEnd(s); end > 0 {
+			return strings.ReplaceAll(s[1:end], "''", "'")
+		}
+	}
+	return s
+}
+
+// yamlFlowBalanced reports whether every flow bracket in s is closed
+func yamlFlowBalanced(s string) bool {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"', '\'':
+			end := yamlQuoteEnd(s[i:])
+			if end < 0 {
+				return false
+			}
+			i += end
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// yamlFlowParser parses flow collections such as [a, b] and {k: v}
+type yamlFlowParser struct {
+	s    string
+	i    int
+	line int
+}
+
+func (f *yamlFlowParser) skipSpace() {
+	for f.i < len(f.s) && (f.s[f.i] == ' ' || f.s[f.i] == '\t') {
+		f.i++
+	}
+}
+
+func (f *yamlFlowParser) parseValue(isKey bool) (*yamlNode, error) {
+	f.skipSpace()
+	if f.i >= len(f.s) {
+		return &yamlNode{Kind: yamlScalar, Line: f.line}, nil
+	}
+
+	switch f.s[f.i] {
+	case '[':
+		f.i++
+		node := &yamlNode{Kind: yamlSequence, Line: f.line}
+		for {
+			f.skipSpace()
+			if f.i >= len(f.s) {
+				return nil, fmt.Errorf("unterminated flow sequence")
+			}
+			if f.s[f.i] == ']' {
+				f.i++
+				return node, nil
+			}
+			item, err := f.parseValue(false)
+			if err != nil {
+				return nil, err
+			}
+			node.Items = append(node.Items, item)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.i++
+		node := &yamlNode{Kind: yamlMapping, Line: f.line}
+		for {
+			f.skipSpace()
+			if f.i >= len(f.s) {
+				return nil, fmt.Errorf("unterminated flow mapping")
+			}
+			if f.s[f.i] == '}' {
+				f.i++
+				return node, nil
+			}
+			key, err := f.parseValue(true)
+			if err != nil {
+				return nil, err
+			}
+			f.skipSpace()
+			value := &yamlNode{Kind: yamlScalar, Line: f.line}
+			if f.i < len(f.s) && f.s[f.i] == ':' {
+				f.i++
+				if value, err = f.parseValue(false); err != nil {
+					return nil, err
+				}
+			}
+			node.Pairs = append(node.Pairs, yamlPair{Key: key.Value, Line: f.line, Value: value})
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '"', '\'':
+		end := yamlQuoteEnd(f.s[f.i:])
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated quoted scalar")
+		}
+		v := unquoteYAMLScalar(f.s[f.i : f.i+end+1])
+		f.i += end + 1
+		return &yamlNode{Kind: yamlScalar, Value: v, Line: f.line}, nil
+	}
+
+	start := f.i
+	for f.i < len(f.s) {
+		c := f.s[f.i]
+		if c == ',' || c == ']' || c == '}' {
+			break
+		}
+		if isKey && c == ':' && (f.i+1 == len(f.s) || strings.ContainsRune(" \t,}", rune(f.s[f.i+1]))) {
+			break
+		}
+		f.i++
+	}
+	return &yamlNode{Kind: yamlScalar, Value: strings.TrimSpace(f.s[start:f.i]), Line: f.line}, nil
+}
+
+// separator consumes a ',' between entries, leaving a closing bracket in place
+func (f *yamlFlowParser) separator(closing byte) error {
+	f.skipSpace()
+	if f.i >= len(f.s) {
+		return fmt.Errorf("unterminated flow collection")
+	}
+	switch f.s[f.i] {
+	case ',':
+		f.i++
+		return nil
+	case closing:
+		return nil
+	}
+	return fmt.Errorf("unexpected %q in flow collection", f.s[f.i])
+}
@@ -0,0 +1,70 @@
+package scanner
+
+import "testing"
+
+// TestParseYAML verifies block, flow, block-scalar and multi-document parsing
+func TestParseYAML(t *testing.T) {
+	input := `# comment
+apiVersion: v1
+metadata:
+  name: "web"   # quoted
+  labels: {app: web, tier: 'front'}
+spec:
+  containers:
+    - name: app
+      args: [--port, "8080"]
+      env:
+      - name: TOKEN
+        value: abc
+  script: |
+    line one
+    line two
+---
+kind: Secret
+`
+	docs, err := parseYAML([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want 2", len(docs))
+	}
+
+	doc := docs[0]
+	if got := doc.Get("metadata").Get("name"); got.String() != "web" || got.Line != 4 {
+		t.Errorf("metadata.name = %q at line %d", got.String(), got.Line)
+	}
+	if got := doc.Get("metadata").Get("labels").Get("tier").String(); got != "front" {
+		t.Errorf("labels.tier = %q, want front", got)
+	}
+
+	containers := doc.Get("spec").Get("containers")
+	if containers == nil || len(containers.Items) != 1 {
+		t.Fatalf("unexpected containers node: %+v", containers)
+	}
+	c := containers.Items[0]
+	if args := c.Get("args"); args == nil || len(args.Items) != 2 || args.Items[1].String() != "8080" {
+		t.Errorf("unexpected args node: %+v", args)
+	}
+	env := c.Get("env")
+	if env == nil || len(env.Items) != 1 {
+		t.Fatalf("unexpected env node: %+v", env)
+	}
+	if v := env.Items[0].Get("value"); v.String() != "abc" || v.Line != 12 {
+		t.Errorf("env value = %q at line %d", v.String(), v.Line)
+	}
+
+	if got := doc.Get("spec").Get("script"); got.String() != "line one\nline two\n" || got.Line != 14 {
+		t.Errorf("script = %q at line %d", got.String(), got.Line)
+	}
+	if got := docs[1].Get("kind").String(); got != "Secret" {
+		t.Errorf("second document kind = %q", got)
+	}
+}
+
+// TestParseYAMLErrors verifies that malformed indentation is rejected
+func TestParseYAMLErrors(t *testing.T) {
+	if _, err := parseYAML([]byte("a:\n    b: 1\n  c: 2\n")); err == nil {
+		t.Error("expected an indentation error")
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "github.com/Zayan-Mohamed/secscan/scanner"
+
+// filterByConfidence drops findings below the minimum confidence
+func filterByConfidence(findings []scanner.Finding, minConfidence float64) []scanner.Finding {
+	if minConfidence <= 0 {
+		return findings
+	}
+	var out []scanner.Finding
+	for _, f := range findings {
+		if f.Confidence >= minConfidence {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// failsThreshold reports whether any finding that is not disproved is at or
+// above the -fail-on severity
+func failsThreshold(findings []scanner.Finding, failOn string) bool {
+	threshold := scanner.SeverityRank(failOn)
+	if threshold == 0 {
+		return false
+	}
+	for _, f := range findings {
+		if !f.Disproved() && scanner.SeverityRank(f.Severity) >= threshold {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Zayan-Mohamed/secscan/scanner"
+)
+
+// TestFailsThreshold verifies -fail-on severity thresholds
+func TestFailsThreshold(t *testing.T) {
+	findings := []scanner.Finding{
+		{Pattern: "generic_secret", Severity: scanner.SeverityMedium},
+		{Pattern: "high_entropy", Severity: scanner.SeverityLow},
+	}
+
+	tests := []struct {
+		failOn string
+		want   bool
+	}{
+		{scanner.SeverityCritical, false},
+		{scanner.SeverityHigh, false},
+		{scanner.SeverityMedium, true},
+		{scanner.SeverityLow, true},
+		{scanner.SeverityNone, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.failOn, func(t *testing.T) {
+			if got := failsThreshold(findings, tt.failOn); got != tt.want {
+				t.Errorf("failsThreshold(%s) = %v, want %v", tt.failOn, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFilterByConfidence verifies -min-confidence filtering
+func TestFilterByConfidence(t *testing.T) {
+	findings := []scanner.Finding{{Confidence: 0.55}, {Confidence: 0.8}, {Confidence: 0.9}}
+
+	if got := filterByConfidence(findings, 0); len(got) != 3 {
+		t.Errorf("min 0 kept %d findings, want 3", len(got))
+	}
+	if got := filterByConfidence(findings, 0.8); len(got) != 2 {
+		t.Errorf("min 0.8 kept %d findings, want 2", len(got))
+	}
+}
+
+// TestLoadConfigFileSeverities verifies the [severity] config section
+func TestLoadConfigFileSeverities(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".secscan.toml")
+	content := `custom_token = "tok_[0-9a-f]{32}"
+
+[severity]
+custom_token = "critical"
+high_entropy = low
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Rules["custom_token"] != "tok_[0-9a-f]{32}" || len(cfg.Rules) != 1 {
+		t.Errorf("unexpected rules: %v", cfg.Rules)
+	}
+
+	sc, err := scanner.New(scanner.WithRules(map[string]string{"custom_token": cfg.Rules["custom_token"], "other_token": "tok2_[0-9a-f]{32}"}), scanner.WithSeverities(cfg.Severities))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := sc.Rules()
+	if rules["custom_token"].Severity != scanner.SeverityCritical {
+		t.Errorf("configured severity = %s, want critical", rules["custom_token"].Severity)
+	}
+	if rules["other_token"].Severity != scanner.SeverityHigh {
+		t.Errorf("custom rule default severity = %s, want high", rules["other_token"].Severity)
+	}
+	if cfg.Severities["high_entropy"] != "low" {
+		t.Errorf("high_entropy severity = %q, want low", cfg.Severities["high_entropy"])
+	}
+}